@@ -18,28 +18,45 @@ package command
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
 // A generic command abstraction.
 type Command struct {
+	ctx                          context.Context
 	cmds                         []*command
 	stdErrWriters, stdOutWriters []io.Writer
 	env                          []string
 	verbose                      bool
 	filter                       *filter
+	rlimitNofile                 *rlimit
+	credential                   *credential
+	tailLines                    int
+	absoluteBinaryOnly           bool
+	logFields                    logrus.Fields
+	tee                          io.Writer
+	timeout                      time.Duration
+	streamTo                     func(line string, isStderr bool)
+	pipeFail                     bool
 }
 
+// ErrNotAbsoluteBinary is returned when running a command with
+// WithAbsoluteBinaryOnly, where at least one binary is not an absolute path.
+var ErrNotAbsoluteBinary = errors.New("binary is not an absolute path")
+
 // The internal command representation.
 type command struct {
 	*exec.Cmd
@@ -52,16 +69,44 @@ type filter struct {
 	replaceAll string
 }
 
+// rlimit is the internally used struct for setting resource limits.
+type rlimit struct {
+	soft, hard uint64
+}
+
+// credential is the internally used struct for running commands as a
+// different user and group.
+type credential struct {
+	uid, gid uint32
+}
+
 // A generic command exit status.
 type Status struct { //nolint: errname
-	waitStatus syscall.WaitStatus
+	waitStatus   syscall.WaitStatus
+	tail         string
+	pipeStatuses []*Status
 	*Stream
 }
 
+// syncWriter serializes concurrent writes to the underlying writer.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// Write writes p to the underlying writer.
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.w.Write(p)
+}
+
 // Stream combines standard output and error.
 type Stream struct { //nolint: errname
-	stdOut string
-	stdErr string
+	stdOut   string
+	stdErr   string
+	combined string
 }
 
 // Commands is an abstraction over multiple Command structures.
@@ -75,9 +120,25 @@ func New(cmd string, args ...string) *Command {
 // NewWithWorkDir creates a new command from the provided workDir and the command
 // arguments.
 func NewWithWorkDir(workDir, cmd string, args ...string) *Command {
+	return newWithContext(context.Background(), workDir, cmd, args...)
+}
+
+// NewWithContext creates a new command from the provided arguments, which is
+// bound to the provided context. If the context gets done before the command
+// finishes, all processes of the command (including every stage of a Pipe
+// chain and their children) get terminated like described in WithTimeout.
+// Running the command then returns an error wrapping the error of the context
+// together with the Status of the terminated command, unless the context was
+// already done before the command could be started.
+func NewWithContext(ctx context.Context, cmd string, args ...string) *Command {
+	return newWithContext(ctx, "", cmd, args...)
+}
+
+func newWithContext(ctx context.Context, workDir, cmd string, args ...string) *Command {
 	return &Command{
+		ctx: ctx,
 		cmds: []*command{{
-			Cmd:        cmdWithDir(workDir, cmd, args...),
+			Cmd:        cmdWithDir(ctx, workDir, cmd, args...),
 			pipeWriter: nil,
 		}},
 		stdErrWriters: []io.Writer{},
@@ -86,16 +147,19 @@ func NewWithWorkDir(workDir, cmd string, args ...string) *Command {
 	}
 }
 
-func cmdWithDir(dir, cmd string, args ...string) *exec.Cmd {
-	c := exec.Command(cmd, args...)
+func cmdWithDir(ctx context.Context, dir, cmd string, args ...string) *exec.Cmd {
+	c := exec.CommandContext(ctx, cmd, args...)
 	c.Dir = dir
 
 	return c
 }
 
-// Pipe creates a new command where the previous should be piped to.
+// Pipe creates a new command where the previous should be piped to. If any
+// stage except the last one fails, running the command returns an error and
+// aborts the whole chain. Use WithPipeFail to run all stages and inspect them
+// via Status.PipeStatuses instead.
 func (c *Command) Pipe(cmd string, args ...string) *Command {
-	pipeCmd := cmdWithDir(c.cmds[0].Dir, cmd, args...)
+	pipeCmd := cmdWithDir(c.ctx, c.cmds[0].Dir, cmd, args...)
 
 	reader, writer := io.Pipe()
 	c.cmds[len(c.cmds)-1].Stdout = writer
@@ -112,6 +176,7 @@ func (c *Command) Pipe(cmd string, args ...string) *Command {
 // Env specifies the environment added to the command. Each entry is of the
 // form "key=value". The environment of the current process is being preserved,
 // while it is possible to overwrite already existing environment variables.
+// Use WithEnvFile to add the environment from a .env file.
 func (c *Command) Env(env ...string) *Command {
 	c.env = append(c.env, env...)
 
@@ -131,10 +196,192 @@ func (c *Command) isVerbose() bool {
 	return GetGlobalVerbose() || c.verbose
 }
 
+// WithRlimitNofile sets the soft and hard limit of open file descriptors
+// (RLIMIT_NOFILE) for every spawned process of the command. The limits are
+// applied via prlimit(2) right after the process has been started, which
+// means that this option is only supported on Linux and a no-op on all other
+// platforms, including Windows. Running the command will fail if the soft
+// limit exceeds the hard limit or if the hard limit exceeds the one of the
+// current process.
+//
+// Because the limits are applied after the start, the process runs with the
+// limits inherited from the current process for a short moment. A process
+// may therefore open more file descriptors than allowed, or spawn children
+// which keep the inherited limits, before the limits are in place. This
+// option is meant to constrain well behaved processes and is no security
+// boundary. The limits are not applied in the current process around the
+// start instead, because a lowered hard limit can not be raised again and
+// the lowered soft limit would apply to all goroutines in the meantime.
+func (c *Command) WithRlimitNofile(soft, hard uint64) *Command {
+	c.rlimitNofile = &rlimit{soft: soft, hard: hard}
+
+	return c
+}
+
+// WithCredential runs every spawned process of the command as the provided
+// user and group ID. Switching to a different user or group requires the
+// current process to run as root, otherwise running the command will fail.
+// This option is not supported on Windows, where running the command will
+// always fail.
+func (c *Command) WithCredential(uid, gid uint32) *Command {
+	c.credential = &credential{uid: uid, gid: gid}
+
+	return c
+}
+
+// WithPipeFail changes the handling of failing stages of a Pipe chain to
+// the one of a shell with pipefail enabled: All stages keep running if one
+// fails and the status of the chain is the one of the last failing stage.
+// Running the command does not return an error in that case, which means
+// that the individual stages can be inspected via Status.PipeStatuses.
+func (c *Command) WithPipeFail() *Command {
+	c.pipeFail = true
+
+	return c
+}
+
+// WithTailLines keeps the most recent n lines of the combined output (stdout
+// and stderr) of the command in memory, which can be retrieved via
+// Status.Tail() afterwards. This is useful to provide a concise context if
+// noisy commands fail. Lines longer than 64 KiB are split to bound the
+// memory usage for output without newlines.
+func (c *Command) WithTailLines(n int) *Command {
+	c.tailLines = n
+
+	return c
+}
+
+// WithAbsoluteBinaryOnly requires every binary of the command (including all
+// stages of a Pipe chain) to be provided as an absolute path, which disables
+// the lookup of the binary via $PATH. Running the command will fail with
+// ErrNotAbsoluteBinary if this is not the case. This protects privileged
+// tooling from executing an unexpected binary because of a manipulated $PATH,
+// but comes at the cost of portability, because the binary location may
+// differ between systems and distributions.
+func (c *Command) WithAbsoluteBinaryOnly() *Command {
+	c.absoluteBinaryOnly = true
+
+	return c
+}
+
+// WithLogFields logs every line of the command output (stdout and stderr) on
+// info level via the global logger with the provided fields attached, for
+// example the command name or a stage, which allows log aggregators to filter
+// the output of individual commands. The originating stream gets added as
+// "stream" field. The lines are logged in addition to the regular output
+// handling, which means also when running the command silently.
+func (c *Command) WithLogFields(fields logrus.Fields) *Command {
+	c.logFields = fields
+
+	return c
+}
+
+// WithTee sets the terminal the output (stdout and stderr) of the command is
+// printed to live, which defaults to os.Stdout and os.Stderr. The output is
+// still captured at the same time, which means that Status.Output() and
+// Status.Error() are populated while the user watches the progress. Contrary
+// to AddWriter, which adds writers next to the terminal, WithTee replaces the
+// terminal itself, for example to print to a dedicated tty or an interactive
+// progress view. Like all printed output, it is skipped by the RunSilent*
+// functions.
+func (c *Command) WithTee(w io.Writer) *Command {
+	c.tee = w
+
+	return c
+}
+
+// WithTimeout limits the runtime of the whole command (including all pipe
+// stages) to the provided duration, on top of the context of the command.
+// Every stage is started in its own process group, which receives a SIGTERM
+// once the timeout expires, followed by a SIGKILL if it is still running
+// after a short grace period. This also terminates any child processes
+// spawned by the command. The same applies to commands created via
+// NewWithContext with a context which can be done. Because of the separate
+// process group, the command does not receive signals sent to the foreground
+// process group of the terminal, like Ctrl-C. On Windows the processes are
+// killed immediately. A zero or negative duration disables the timeout.
+// Running the command returns an error wrapping context.DeadlineExceeded
+// once the timeout expired, together with the unsuccessful Status of the
+// terminated command, including the output captured until then.
+func (c *Command) WithTimeout(timeout time.Duration) *Command {
+	c.timeout = timeout
+
+	return c
+}
+
+// WithStdin sets the standard input of the command to the provided reader.
+// For a Pipe chain, it becomes the input of the first command.
+func (c *Command) WithStdin(r io.Reader) *Command {
+	c.cmds[0].Stdin = r
+
+	return c
+}
+
+// WithStdinString sets the standard input of the command to the provided
+// string, like WithStdin.
+func (c *Command) WithStdinString(s string) *Command {
+	return c.WithStdin(strings.NewReader(s))
+}
+
+// StreamTo calls fn for every line of the command output (stdout and stderr)
+// while the command is running, for example to update a progress view or to
+// parse its status. The line is passed without the trailing newline, a final
+// line without newline is passed once the command finished. isStderr
+// indicates the originating stream. The calls are serialized, which means fn
+// does not have to be safe for concurrent use. Like WithLogFields, the lines
+// are passed in addition to the regular output handling, which means also
+// when running the command silently.
+func (c *Command) StreamTo(fn func(line string, isStderr bool)) *Command {
+	c.streamTo = fn
+
+	return c
+}
+
+// newStreamWriters returns the line writers for stdout and stderr passing
+// every line to the StreamTo callback of the command.
+func (c *Command) newStreamWriters() []*lineWriter {
+	var mu sync.Mutex
+
+	newWriter := func(isStderr bool) *lineWriter {
+		return &lineWriter{add: func(line string) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			c.streamTo(line, isStderr)
+		}}
+	}
+
+	return []*lineWriter{newWriter(false), newWriter(true)}
+}
+
+// newLogWriter returns a line writer which logs every line of the output
+// stream with the log fields of the command attached.
+func (c *Command) newLogWriter(stream string) *lineWriter {
+	entry := logrus.WithFields(c.logFields).WithField("stream", stream)
+
+	return &lineWriter{add: func(line string) { entry.Info(line) }}
+}
+
+// verifyAbsoluteBinaries returns an error if absolute binaries are required
+// but any of the commands has been created without an absolute path.
+func (c *Command) verifyAbsoluteBinaries() error {
+	if !c.absoluteBinaryOnly {
+		return nil
+	}
+
+	for _, cmd := range c.cmds {
+		if !filepath.IsAbs(cmd.Args[0]) {
+			return fmt.Errorf("%w: %s", ErrNotAbsoluteBinary, cmd.Args[0])
+		}
+	}
+
+	return nil
+}
+
 // Add a command with the same working directory as well as verbosity mode.
 // Returns a new Commands instance.
 func (c *Command) Add(cmd string, args ...string) Commands {
-	addCmd := NewWithWorkDir(c.cmds[0].Dir, cmd, args...)
+	addCmd := newWithContext(c.ctx, c.cmds[0].Dir, cmd, args...)
 	addCmd.verbose = c.verbose
 	addCmd.filter = c.filter
 
@@ -143,7 +390,8 @@ func (c *Command) Add(cmd string, args ...string) Commands {
 
 // AddWriter can be used to add an additional output (stdout) and error
 // (stderr) writer to the command, for example when having the need to log to
-// files.
+// files. The writer receives the output in addition to the terminal, but only
+// if the output is printed (not when running silently).
 func (c *Command) AddWriter(writer io.Writer) *Command {
 	c.AddOutputWriter(writer)
 	c.AddErrorWriter(writer)
@@ -269,11 +517,28 @@ func (c *Command) RunSilentSuccess() error {
 
 // run is the internal run method.
 func (c *Command) run(printOutput bool) (res *Status, err error) {
+	if err := c.verifyAbsoluteBinaries(); err != nil {
+		return nil, err
+	}
+
+	ctx := c.ctx
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	// Commands bound to a context which can be done get terminated including
+	// their children.
+	terminateGroup := ctx.Done() != nil
+
 	var runErr error
 
 	stdOutBuffer := &bytes.Buffer{}
 	stdErrBuffer := &bytes.Buffer{}
-	status := &Status{Stream: &Stream{}}
+	combinedBuffer := &bytes.Buffer{}
 
 	type done struct {
 		stdout error
@@ -282,9 +547,118 @@ func (c *Command) run(printOutput bool) (res *Status, err error) {
 
 	doneChan := make(chan done, 1)
 
+	// Whether the output of the last command has been copied completely.
+	var copied bool
+
 	var stdOutWriter io.Writer
 
+	var tail *ringBuffer
+
+	tailWriters := []*lineWriter{}
+
+	if c.tailLines > 0 {
+		tail = newRingBuffer(c.tailLines)
+		tailWriters = append(tailWriters, &lineWriter{add: tail.add}, &lineWriter{add: tail.add})
+	}
+
+	logWriters := []*lineWriter{}
+
+	if c.logFields != nil {
+		logWriters = append(logWriters, c.newLogWriter("stdout"), c.newLogWriter("stderr"))
+	}
+
+	streamWriters := []*lineWriter{}
+
+	if c.streamTo != nil {
+		streamWriters = c.newStreamWriters()
+	}
+
+	// The stderr of every stage of a pipe chain except the last one.
+	stageErrBuffers := make([]*bytes.Buffer, len(c.cmds))
+	exitErr := &exec.ExitError{}
+
+	// newStatus returns the status of the command once all started processes
+	// have been waited for.
+	newStatus := func() *Status {
+		status := &Status{Stream: &Stream{
+			stdOut:   stdOutBuffer.String(),
+			stdErr:   stdErrBuffer.String(),
+			combined: combinedBuffer.String(),
+		}}
+
+		stageStatuses := make([]*Status, len(c.cmds))
+		for i, cmd := range c.cmds {
+			stageStatuses[i] = &Status{waitStatus: processWaitStatus(cmd.Cmd), Stream: &Stream{}}
+
+			if buf := stageErrBuffers[i]; buf != nil {
+				stageStatuses[i].stdErr = buf.String()
+			}
+		}
+
+		stageStatuses[len(c.cmds)-1].Stream = status.Stream
+		status.pipeStatuses = stageStatuses
+
+		for _, w := range logWriters {
+			w.flush()
+		}
+
+		for _, w := range streamWriters {
+			w.flush()
+		}
+
+		if tail != nil {
+			for _, w := range tailWriters {
+				w.flush()
+			}
+
+			status.tail = tail.String()
+		}
+
+		// Like bash's pipefail: the status of a chain is the one of the last
+		// failing stage.
+		for i := len(stageStatuses) - 1; i >= 0; i-- {
+			if !stageStatuses[i].Success() {
+				status.waitStatus = stageStatuses[i].waitStatus
+
+				break
+			}
+		}
+
+		return status
+	}
+
+	// The number of started and already waited for processes, used to tear
+	// down the whole pipe chain on failure.
+	var started, waited int
+
+	defer func() {
+		if err != nil {
+			c.teardown(started, waited)
+
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				err = fmt.Errorf("%w: %w", err, ctxErr)
+
+				// Provide the output captured until the processes got
+				// terminated.
+				if res == nil && started > 0 {
+					if started == len(c.cmds) && !copied {
+						<-doneChan
+					}
+
+					res = newStatus()
+				}
+			}
+		}
+	}()
+
 	for i, cmd := range c.cmds {
+		// Capture stderr of all stages, which are not the last one, to make
+		// them available via PipeStatuses.
+		if i+1 < len(c.cmds) && cmd.Stderr == nil {
+			stageErrBuffers[i] = &bytes.Buffer{}
+			cmd.Stderr = stageErrBuffers[i]
+		}
+
 		// Last command handling
 		if i+1 == len(c.cmds) {
 			stdout, err := cmd.StdoutPipe()
@@ -300,17 +674,44 @@ func (c *Command) run(printOutput bool) (res *Status, err error) {
 			var stdErrWriter io.Writer
 
 			if printOutput {
+				var terminalOut, terminalErr io.Writer = os.Stdout, os.Stderr
+				if c.tee != nil {
+					// Both streams are copied concurrently into the tee.
+					tee := &syncWriter{w: c.tee}
+					terminalOut, terminalErr = tee, tee
+				}
+
 				stdOutWriter = io.MultiWriter(append(
-					[]io.Writer{os.Stdout, stdOutBuffer}, c.stdOutWriters...,
+					[]io.Writer{terminalOut, stdOutBuffer}, c.stdOutWriters...,
 				)...)
 				stdErrWriter = io.MultiWriter(append(
-					[]io.Writer{os.Stderr, stdErrBuffer}, c.stdErrWriters...,
+					[]io.Writer{terminalErr, stdErrBuffer}, c.stdErrWriters...,
 				)...)
 			} else {
 				stdOutWriter = stdOutBuffer
 				stdErrWriter = stdErrBuffer
 			}
 
+			if tail != nil {
+				stdOutWriter = io.MultiWriter(stdOutWriter, tailWriters[0])
+				stdErrWriter = io.MultiWriter(stdErrWriter, tailWriters[1])
+			}
+
+			if len(logWriters) > 0 {
+				stdOutWriter = io.MultiWriter(stdOutWriter, logWriters[0])
+				stdErrWriter = io.MultiWriter(stdErrWriter, logWriters[1])
+			}
+
+			if len(streamWriters) > 0 {
+				stdOutWriter = io.MultiWriter(stdOutWriter, streamWriters[0])
+				stdErrWriter = io.MultiWriter(stdErrWriter, streamWriters[1])
+			}
+
+			// Both streams are copied concurrently into the combined buffer.
+			combinedWriter := &syncWriter{w: combinedBuffer}
+			stdOutWriter = io.MultiWriter(stdOutWriter, combinedWriter)
+			stdErrWriter = io.MultiWriter(stdErrWriter, combinedWriter)
+
 			go func() {
 				var stdoutErr, stderrErr error
 
@@ -361,12 +762,54 @@ func (c *Command) run(printOutput bool) (res *Status, err error) {
 
 		cmd.Env = append(os.Environ(), c.env...)
 
+		if c.rlimitNofile != nil {
+			if err := verifyRlimitNofile(c.rlimitNofile); err != nil {
+				return nil, fmt.Errorf("verify open file descriptor limit: %w", err)
+			}
+		}
+
+		if c.credential != nil {
+			if err := setCredential(cmd.Cmd, c.credential); err != nil {
+				return nil, fmt.Errorf("set credential: %w", err)
+			}
+		}
+
+		if terminateGroup {
+			setProcessGroup(cmd.Cmd)
+
+			// The whole process group gets terminated below instead of
+			// killing only the process once the context is done.
+			cmd.Cancel = func() error { return nil }
+		}
+
 		if err := cmd.Start(); err != nil {
 			return nil, err
 		}
 
+		started++
+
+		if terminateGroup {
+			process := cmd.Process
+			stop := context.AfterFunc(ctx, func() {
+				terminateProcessGroup(process, terminationGracePeriod)
+			})
+
+			defer stop()
+		}
+
+		if c.rlimitNofile != nil {
+			if err := setRlimitNofile(cmd.Process.Pid, c.rlimitNofile); err != nil {
+				return nil, fmt.Errorf("set open file descriptor limit: %w", err)
+			}
+		}
+
 		if i > 0 {
-			if err := c.cmds[i-1].Wait(); err != nil {
+			err := c.cmds[i-1].Wait()
+			waited++
+
+			// Failing stages abort the chain, unless pipefail is enabled and
+			// the context is not done.
+			if err != nil && (!c.pipeFail || !errors.As(err, &exitErr) || ctx.Err() != nil) {
 				return nil, err
 			}
 		}
@@ -380,6 +823,8 @@ func (c *Command) run(printOutput bool) (res *Status, err error) {
 		// Wait for last command in the pipe to finish
 		if i+1 == len(c.cmds) {
 			err := <-doneChan
+			copied = true
+
 			if err.stdout != nil && strings.Contains(err.stdout.Error(), os.ErrClosed.Error()) {
 				return nil, fmt.Errorf("unable to copy stdout: %w", err.stdout)
 			}
@@ -389,17 +834,18 @@ func (c *Command) run(printOutput bool) (res *Status, err error) {
 			}
 
 			runErr = cmd.Wait()
+			waited++
+
+			if runErr != nil && ctx.Err() != nil {
+				return nil, runErr
+			}
 		}
 	}
 
-	status.stdOut = stdOutBuffer.String()
-	status.stdErr = stdErrBuffer.String()
+	status := newStatus()
 
-	exitErr := &exec.ExitError{}
 	if errors.As(runErr, &exitErr) {
-		if waitStatus, ok := exitErr.Sys().(syscall.WaitStatus); ok {
-			status.waitStatus = waitStatus
-
+		if _, ok := exitErr.Sys().(syscall.WaitStatus); ok {
 			return status, nil
 		}
 	}
@@ -407,6 +853,34 @@ func (c *Command) run(printOutput bool) (res *Status, err error) {
 	return status, runErr
 }
 
+// processWaitStatus returns the wait status of the exited process.
+func processWaitStatus(cmd *exec.Cmd) (waitStatus syscall.WaitStatus) {
+	if cmd.ProcessState == nil {
+		return waitStatus
+	}
+
+	if ws, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok {
+		waitStatus = ws
+	}
+
+	return waitStatus
+}
+
+// teardown closes all pipes of the chain and kills as well as reaps all
+// started processes which have not been waited for yet.
+func (c *Command) teardown(started, waited int) {
+	for _, cmd := range c.cmds {
+		if cmd.pipeWriter != nil {
+			_ = cmd.pipeWriter.Close()
+		}
+	}
+
+	for _, cmd := range c.cmds[waited:started] {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}
+}
+
 // Success returns if a Status was successful.
 func (s *Status) Success() bool {
 	return s.waitStatus.ExitStatus() == 0
@@ -417,6 +891,22 @@ func (s *Status) ExitCode() int {
 	return s.waitStatus.ExitStatus()
 }
 
+// PipeStatuses returns the individual status of every stage of a Pipe chain
+// in their order of execution. The standard error of all stages is available
+// via Error(), while only the last stage provides its standard output. The
+// status of a command without any Pipe contains only a single element. Only
+// commands using WithPipeFail return a status for failing stages other than
+// the last one.
+func (s *Status) PipeStatuses() []*Status {
+	return s.pipeStatuses
+}
+
+// Tail returns the most recent lines of the combined output if the command
+// was configured by using WithTailLines, otherwise an empty string.
+func (s *Status) Tail() string {
+	return s.tail
+}
+
 // Output returns stdout of the command status.
 func (s *Stream) Output() string {
 	return s.stdOut
@@ -433,6 +923,16 @@ func (s *Stream) Error() string {
 	return s.stdErr
 }
 
+// CombinedOutput returns stdout and stderr of the command status interleaved
+// in the order they have been read from the process. Both streams are read
+// concurrently, which means that writes happening at nearly the same time may
+// still appear in a different order. The order gets lost entirely if the
+// output is filtered, because filtering reads each stream as a whole. Only
+// the stderr of the last stage of a pipe chain is part of the output.
+func (s *Stream) CombinedOutput() string {
+	return s.combined
+}
+
 // Execute is a convenience function which creates a new Command, executes it
 // and evaluates its status.
 func Execute(cmd string, args ...string) error {
@@ -468,10 +968,45 @@ func Available(commands ...string) (ok bool) {
 	return ok
 }
 
+// LookPath searches for the executable `name` within the current `$PATH`
+// environment and returns its absolute path and true if found. If the
+// command is not available, an empty string and false are returned.
+func LookPath(name string) (path string, ok bool) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", false
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path, true
+	}
+
+	return abs, true
+}
+
+// AvailableWithPaths resolves the specified `commands` within the current
+// `$PATH` environment and returns a map of each command to its absolute path.
+// The path is empty for commands which are not available.
+func AvailableWithPaths(commands ...string) map[string]string {
+	res := make(map[string]string, len(commands))
+
+	for _, command := range commands {
+		path, ok := LookPath(command)
+		if !ok {
+			logrus.Warnf("Unable to find command %q in $PATH", command)
+		}
+
+		res[command] = path
+	}
+
+	return res
+}
+
 // Add adds another command with the same working directory as well as
 // verbosity mode to the Commands.
 func (c Commands) Add(cmd string, args ...string) Commands {
-	addCmd := NewWithWorkDir(c[0].cmds[0].Dir, cmd, args...)
+	addCmd := newWithContext(c[0].ctx, c[0].cmds[0].Dir, cmd, args...)
 	addCmd.verbose = c[0].verbose
 	addCmd.filter = c[0].filter
 
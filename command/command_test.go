@@ -18,9 +18,21 @@ package command
 
 import (
 	"bytes"
+	"context"
+	"fmt"
+	"math"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/require"
 )
 
@@ -167,6 +179,26 @@ func TestAvailableFailure(t *testing.T) {
 	require.False(t, res)
 }
 
+func TestLookPathSuccess(t *testing.T) {
+	path, ok := LookPath("echo")
+	require.True(t, ok)
+	require.True(t, filepath.IsAbs(path))
+	require.FileExists(t, path)
+}
+
+func TestLookPathFailure(t *testing.T) {
+	path, ok := LookPath("this-command-should-not-exist")
+	require.False(t, ok)
+	require.Empty(t, path)
+}
+
+func TestAvailableWithPaths(t *testing.T) {
+	res := AvailableWithPaths("echo", "this-command-should-not-exist")
+	require.Len(t, res, 2)
+	require.True(t, filepath.IsAbs(res["echo"]))
+	require.Empty(t, res["this-command-should-not-exist"])
+}
+
 func TestSuccessRunSuccess(t *testing.T) {
 	require.NoError(t, New("echo", "hi").RunSuccess())
 }
@@ -255,6 +287,26 @@ func TestSuccessLogWriterSilent(t *testing.T) {
 	require.Empty(t, content)
 }
 
+func TestWithTee(t *testing.T) {
+	tee := &bytes.Buffer{}
+
+	res, err := New("bash", "-c", "echo out; >&2 echo err").
+		WithTee(tee).
+		RunSuccessOutput()
+	require.NoError(t, err)
+	require.Equal(t, "out\n", res.Output())
+	require.Equal(t, "err\n", res.Error())
+	require.Contains(t, tee.String(), "out\n")
+	require.Contains(t, tee.String(), "err\n")
+
+	tee.Reset()
+
+	out, err := New("echo", "Hello World").WithTee(tee).RunSilentSuccessOutput()
+	require.NoError(t, err)
+	require.Equal(t, "Hello World", out.OutputTrimNL())
+	require.Empty(t, tee.String())
+}
+
 func TestSuccessLogWriterStdErr(t *testing.T) {
 	f, err := os.CreateTemp(t.TempDir(), "log")
 	require.NoError(t, err)
@@ -345,6 +397,65 @@ func TestEnv(t *testing.T) {
 	require.Equal(t, "123\nbar\ntest", res.OutputTrimNL())
 }
 
+func TestWithEnvFile(t *testing.T) {
+	t.Setenv("FOO", "test") // overwritten
+
+	envFile := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(envFile, []byte(`
+# comment
+TEST=123
+FOO = bar # trailing comment
+DOUBLE="a # b\tc"
+SINGLE='$HOME \n'
+EMPTY=
+`), 0o600))
+
+	cmd := New("sh", "-c", `echo $TEST; echo $FOO; printf '%s\n' "$DOUBLE" "$SINGLE"; echo "[$EMPTY]"`)
+	require.NoError(t, cmd.WithEnvFile(envFile))
+
+	res, err := cmd.RunSuccessOutput()
+	require.NoError(t, err)
+	require.Equal(t, "123\nbar\na # b\tc\n$HOME \\n\n[]", res.OutputTrimNL())
+
+	require.Error(t, New("true").WithEnvFile(filepath.Join(t.TempDir(), "missing")))
+
+	for _, line := range []string{
+		"NO_SEPARATOR",
+		"=value",
+		"MY KEY=value",
+		`KEY="unterminated`,
+		`KEY='value' trailing`,
+		`KEY="\q"`,
+	} {
+		require.NoError(t, os.WriteFile(envFile, []byte(line), 0o600))
+
+		cmd := New("true")
+		require.Error(t, cmd.WithEnvFile(envFile), line)
+		require.Empty(t, cmd.env)
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	for _, tc := range []struct {
+		line, expected string
+	}{
+		{line: "A=x", expected: "A=x"},
+		{line: "A=x#y", expected: "A=x#y"},
+		{line: "A=x # comment", expected: "A=x"},
+		{line: "A=x\t# comment", expected: "A=x"},
+		{line: "A='x' # don't", expected: "A=x"},
+		{line: "A='x'\t# don't", expected: "A=x"},
+		{line: `A="x" # say "hi"`, expected: "A=x"},
+		{line: `A="a \"b\" c" # "d"`, expected: `A=a "b" c`},
+		{line: `A="a # b" # c`, expected: "A=a # b"},
+		{line: `A='a \' # b`, expected: `A=a \`},
+	} {
+		env, err := parseEnvFile(strings.NewReader(tc.line))
+		require.NoError(t, err, tc.line)
+		require.Equal(t, []string{tc.expected}, env, tc.line)
+	}
+}
+
 func TestFilterStdout(t *testing.T) {
 	cmd, err := New("echo", "-n", "1 2 2 3").Filter("[25]", "0")
 	require.NoError(t, err)
@@ -364,3 +475,346 @@ func TestFilterStderr(t *testing.T) {
 	require.Equal(t, "my ***", out.Error())
 	require.Empty(t, out.Output())
 }
+
+func TestWithRlimitNofile(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("resource limits are only supported on linux")
+	}
+
+	// The limits are applied right after the process has been started, which
+	// is racy by design. Sleep to not read the limits before they are set.
+	res, err := New("sh", "-c", "sleep 0.5; ulimit -Sn; ulimit -Hn").
+		WithRlimitNofile(512, 1024).
+		RunSilentSuccessOutput()
+	require.NoError(t, err)
+	require.Equal(t, "512\n1024", res.OutputTrimNL())
+}
+
+func TestWithRlimitNofileFailure(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("resource limits are only supported on linux")
+	}
+
+	for _, tc := range []struct{ soft, hard uint64 }{
+		{soft: 1024, hard: 512},
+		{soft: 512, hard: math.MaxUint64},
+	} {
+		res, err := New("echo").WithRlimitNofile(tc.soft, tc.hard).Run()
+		require.Error(t, err)
+		require.Nil(t, res)
+	}
+}
+
+func TestWithTailLines(t *testing.T) {
+	res, err := New("bash", "-c", "for i in $(seq 1 100); do echo $i; done").
+		WithTailLines(3).
+		RunSilent()
+	require.NoError(t, err)
+	require.True(t, res.Success())
+	require.Equal(t, "98\n99\n100", res.Tail())
+
+	res, err = New("bash", "-c", ">&2 echo -n error; exit 1").WithTailLines(3).RunSilent()
+	require.NoError(t, err)
+	require.False(t, res.Success())
+	require.Equal(t, "error", res.Tail())
+
+	res, err = New("echo", "hi").RunSilent()
+	require.NoError(t, err)
+	require.Empty(t, res.Tail())
+}
+
+func TestLineWriterMaxLineLength(t *testing.T) {
+	lines := []string{}
+	w := &lineWriter{add: func(line string) { lines = append(lines, line) }}
+
+	// Output without newlines must not be buffered indefinitely
+	chunk := bytes.Repeat([]byte{'x'}, 1000)
+	for range 3 * maxLineLength / len(chunk) {
+		_, err := w.Write(chunk)
+		require.NoError(t, err)
+		require.Less(t, len(w.partial), maxLineLength)
+	}
+
+	_, err := w.Write([]byte("\nlast"))
+	require.NoError(t, err)
+	w.flush()
+
+	rest := 3*maxLineLength/len(chunk)*len(chunk) - 2*maxLineLength
+	require.Len(t, lines, 4)
+	require.Len(t, lines[0], maxLineLength)
+	require.Len(t, lines[1], maxLineLength)
+	require.Len(t, lines[2], rest)
+	require.Equal(t, "last", lines[3])
+}
+
+func TestWithAbsoluteBinaryOnly(t *testing.T) {
+	res, err := New("echo", "hi").WithAbsoluteBinaryOnly().RunSilent()
+	require.ErrorIs(t, err, ErrNotAbsoluteBinary)
+	require.Nil(t, res)
+
+	echo, err := exec.LookPath("echo")
+	require.NoError(t, err)
+	echo, err = filepath.Abs(echo)
+	require.NoError(t, err)
+
+	res, err = New(echo, "hi").Pipe("cat").WithAbsoluteBinaryOnly().RunSilent()
+	require.ErrorIs(t, err, ErrNotAbsoluteBinary)
+	require.Nil(t, res)
+
+	out, err := New(echo, "hi").WithAbsoluteBinaryOnly().RunSilentSuccessOutput()
+	require.NoError(t, err)
+	require.Equal(t, "hi", out.OutputTrimNL())
+}
+
+func TestWithLogFields(t *testing.T) {
+	hook := logtest.NewGlobal()
+	defer hook.Reset()
+
+	res, err := New("bash", "-c", "echo out; >&2 echo err; echo -n last").
+		WithLogFields(logrus.Fields{"command": "test", "stage": "build"}).
+		RunSilent()
+	require.NoError(t, err)
+	require.True(t, res.Success())
+
+	lines := map[string]string{}
+
+	for _, entry := range hook.AllEntries() {
+		if entry.Data["command"] != "test" {
+			continue
+		}
+
+		require.Equal(t, logrus.InfoLevel, entry.Level)
+		require.Equal(t, "build", entry.Data["stage"])
+
+		stream, ok := entry.Data["stream"].(string)
+		require.True(t, ok)
+
+		lines[entry.Message] = stream
+	}
+
+	require.Equal(t, map[string]string{
+		"out":  "stdout",
+		"err":  "stderr",
+		"last": "stdout",
+	}, lines)
+}
+
+func TestNewWithContextPipeCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	cmd := NewWithContext(ctx, "echo", "hi").Pipe("sleep", "10").Pipe("cat")
+
+	start := time.Now()
+	res, err := cmd.RunSilent()
+	require.Error(t, err)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.NotNil(t, res)
+	require.False(t, res.Success())
+	require.Len(t, res.PipeStatuses(), 3)
+	require.False(t, res.PipeStatuses()[1].Success())
+	require.Less(t, time.Since(start), 5*time.Second)
+
+	for _, c := range cmd.cmds {
+		require.NotNil(t, c.ProcessState)
+	}
+}
+
+func TestNewWithContextCancelTerminatesChildren(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("requires sh and procfs on linux")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var pid int
+
+	// Cancel as soon as the grandchild is running
+	res, err := NewWithContext(ctx, "sh", "-c", "sleep 100 >/dev/null 2>&1 & echo $!; wait").
+		StreamTo(func(line string, _ bool) {
+			pid, _ = strconv.Atoi(line)
+			cancel()
+		}).
+		RunSilent()
+	require.ErrorIs(t, err, context.Canceled)
+	require.NotNil(t, res)
+	require.False(t, res.Success())
+	require.Equal(t, fmt.Sprintf("%d\n", pid), res.Output())
+	require.Positive(t, pid)
+
+	// The grandchild may remain a zombie if nobody reaps it
+	require.Eventually(t, func() bool {
+		stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			return os.IsNotExist(err)
+		}
+
+		_, state, _ := strings.Cut(string(stat[bytes.LastIndexByte(stat, ')')+1:]), " ")
+
+		return strings.HasPrefix(state, "Z")
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestNewWithContextSuccess(t *testing.T) {
+	res, err := NewWithContext(context.Background(), "echo", "-n", "hi").
+		Pipe("cat").
+		RunSilent()
+	require.NoError(t, err)
+	require.True(t, res.Success())
+	require.Equal(t, "hi", res.Output())
+}
+
+func TestWithTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sleep and sh on unix")
+	}
+
+	for _, cmd := range []*Command{
+		New("sleep", "10"),
+		// The child process keeps stdout open and needs to be terminated as
+		// well for the command to return.
+		New("sh", "-c", "sleep 10; true"),
+		New("echo", "hi").Pipe("sleep", "10").Pipe("cat"),
+	} {
+		start := time.Now()
+		res, err := cmd.WithTimeout(100 * time.Millisecond).RunSilent()
+		require.Error(t, err)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.NotNil(t, res)
+		require.False(t, res.Success())
+		require.Less(t, time.Since(start), 2*time.Second)
+	}
+
+	// The output until the timeout is available
+	res, err := New("sh", "-c", "echo started; >&2 echo error; sleep 10").
+		WithTimeout(100 * time.Millisecond).
+		RunSilent()
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.False(t, res.Success())
+	require.Equal(t, "started\n", res.Output())
+	require.Equal(t, "error\n", res.Error())
+
+	// The process got terminated by SIGTERM
+	require.True(t, res.waitStatus.Signaled())
+	require.Equal(t, syscall.SIGTERM, res.waitStatus.Signal())
+}
+
+func TestWithTimeoutSuccess(t *testing.T) {
+	res, err := New("echo", "-n", "hi").WithTimeout(time.Minute).RunSilent()
+	require.NoError(t, err)
+	require.True(t, res.Success())
+	require.Equal(t, "hi", res.Output())
+}
+
+func TestCombinedOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh on unix")
+	}
+
+	res, err := New(
+		"sh", "-c",
+		"echo out1; sleep 0.1; echo err1 >&2; sleep 0.1; echo out2; sleep 0.1; echo err2 >&2",
+	).RunSilentSuccessOutput()
+	require.NoError(t, err)
+	require.Equal(t, "out1\nout2\n", res.Output())
+	require.Equal(t, "err1\nerr2\n", res.Error())
+	require.Equal(t, "out1\nerr1\nout2\nerr2\n", res.CombinedOutput())
+}
+
+func TestStreamTo(t *testing.T) {
+	type line struct {
+		text     string
+		isStderr bool
+	}
+
+	lines := []line{}
+
+	res, err := New("bash", "-c", "echo a; sleep 0.1; echo b >&2; sleep 0.1; echo c").
+		StreamTo(func(text string, isStderr bool) {
+			lines = append(lines, line{text, isStderr})
+		}).
+		RunSilentSuccessOutput()
+	require.NoError(t, err)
+	require.Equal(t, "a\nc\n", res.Output())
+	require.Equal(t, []line{
+		{"a", false},
+		{"b", true},
+		{"c", false},
+	}, lines)
+}
+
+func TestWithStdin(t *testing.T) {
+	res, err := New("cat").WithStdinString("hello\nworld\n").RunSilentSuccessOutput()
+	require.NoError(t, err)
+	require.Equal(t, "hello\nworld\n", res.Output())
+
+	res, err = New("cat").
+		Pipe("tr", "a-z", "A-Z").
+		WithStdin(bytes.NewBufferString("piped")).
+		RunSilentSuccessOutput()
+	require.NoError(t, err)
+	require.Equal(t, "PIPED", res.Output())
+}
+
+func TestWithCredential(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() != 0 {
+		t.Skip("requires to run as root on unix")
+	}
+
+	res, err := New("id", "-u").WithCredential(65534, 65534).RunSilentSuccessOutput()
+	require.NoError(t, err)
+	require.Equal(t, "65534", res.OutputTrimNL())
+
+	res, err = New("id", "-g").WithCredential(65534, 65534).RunSilentSuccessOutput()
+	require.NoError(t, err)
+	require.Equal(t, "65534", res.OutputTrimNL())
+}
+
+func TestWithCredentialFailure(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("requires to run as non root on unix")
+	}
+
+	res, err := New("id", "-u").WithCredential(0, 0).RunSilent()
+	require.Error(t, err)
+	require.Nil(t, res)
+}
+
+func TestPipeStatuses(t *testing.T) {
+	// Failing stages abort the chain by default
+	res, err := New("echo", "hi").
+		Pipe("bash", "-c", "cat; exit 3").
+		Pipe("cat").
+		RunSilent()
+	require.Error(t, err)
+	require.Nil(t, res)
+
+	res, err = New("echo", "hi").
+		Pipe("bash", "-c", "cat; >&2 echo -n failed; exit 3").
+		Pipe("cat").
+		WithPipeFail().
+		RunSilent()
+	require.NoError(t, err)
+	require.False(t, res.Success())
+	require.Equal(t, 3, res.ExitCode())
+	require.Equal(t, "hi\n", res.Output())
+
+	statuses := res.PipeStatuses()
+	require.Len(t, statuses, 3)
+
+	require.True(t, statuses[0].Success())
+	require.Empty(t, statuses[0].Error())
+
+	require.False(t, statuses[1].Success())
+	require.Equal(t, 3, statuses[1].ExitCode())
+	require.Equal(t, "failed", statuses[1].Error())
+
+	require.True(t, statuses[2].Success())
+	require.Equal(t, "hi\n", statuses[2].Output())
+
+	res, err = New("echo", "hi").RunSilent()
+	require.NoError(t, err)
+	require.Len(t, res.PipeStatuses(), 1)
+	require.True(t, res.PipeStatuses()[0].Success())
+}
@@ -0,0 +1,50 @@
+//go:build !windows
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setCredential configures the command to run as the provided user and group.
+func setCredential(cmd *exec.Cmd, cred *credential) error {
+	isRoot := os.Geteuid() == 0
+	if !isRoot && (int(cred.uid) != os.Geteuid() || int(cred.gid) != os.Getegid()) {
+		return fmt.Errorf(
+			"switching to uid %d and gid %d requires root privileges",
+			cred.uid, cred.gid,
+		)
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.Credential = &syscall.Credential{
+		Uid: cred.uid,
+		Gid: cred.gid,
+		// Dropping the supplementary groups requires root privileges.
+		NoSetGroups: !isRoot,
+	}
+
+	return nil
+}
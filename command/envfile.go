@@ -0,0 +1,140 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// WithEnvFile reads the environment from the provided .env file and adds it
+// to the command via Env. The file has to contain one "KEY=VALUE" entry per
+// line, while empty lines and lines starting with "#" are ignored. Values can
+// be enclosed in double quotes (supporting Go escape sequences) or single
+// quotes (taken literally). Values end at a "#" comment which is preceded by
+// whitespace, unless the "#" is part of a quoted value. An error
+// is returned if the file cannot be read or contains a malformed line, in
+// which case the environment of the command stays unchanged.
+func (c *Command) WithEnvFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open env file: %w", err)
+	}
+	defer f.Close()
+
+	env, err := parseEnvFile(f)
+	if err != nil {
+		return fmt.Errorf("parse env file %s: %w", path, err)
+	}
+
+	c.Env(env...)
+
+	return nil
+}
+
+// parseEnvFile parses the .env formatted data of the reader into a slice of
+// "KEY=VALUE" entries.
+func parseEnvFile(r io.Reader) ([]string, error) {
+	env := []string{}
+	scanner := bufio.NewScanner(r)
+
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+
+		if !found || key == "" || strings.ContainsAny(key, " \t\"'") {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE, got %q", lineNo, line)
+		}
+
+		value, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: value of %s: %w", lineNo, key, err)
+		}
+
+		env = append(env, key+"="+value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read lines: %w", err)
+	}
+
+	return env, nil
+}
+
+// parseEnvValue unquotes the provided value or strips a trailing comment if
+// it is unquoted.
+func parseEnvValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	quote := value[0]
+	if quote != '"' && quote != '\'' {
+		for i := 1; i < len(value); i++ {
+			if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+				return strings.TrimSpace(value[:i]), nil
+			}
+		}
+
+		return value, nil
+	}
+
+	// Find the first closing quote, which is not escaped within double quotes
+	end := -1
+
+	for i := 1; i < len(value); i++ {
+		if quote == '"' && value[i] == '\\' {
+			i++
+
+			continue
+		}
+
+		if value[i] == quote {
+			end = i
+
+			break
+		}
+	}
+
+	if end < 0 {
+		return "", fmt.Errorf("missing closing quote in %s", value)
+	}
+
+	if rest := strings.TrimSpace(value[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("unexpected content after closing quote: %s", rest)
+	}
+
+	if quote == '\'' {
+		return value[1:end], nil
+	}
+
+	unquoted, err := strconv.Unquote(value[:end+1])
+	if err != nil {
+		return "", fmt.Errorf("unquote %s: %w", value[:end+1], err)
+	}
+
+	return unquoted, nil
+}
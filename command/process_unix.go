@@ -0,0 +1,60 @@
+//go:build !windows
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// terminationGracePeriod is the time a process group gets to exit after
+// receiving a SIGTERM, before it gets killed.
+var terminationGracePeriod = 5 * time.Second
+
+// setProcessGroup configures the command to run in its own process group.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// terminateProcessGroup sends a SIGTERM to the process group of the provided
+// process and a SIGKILL if the group still exists after the grace period.
+func terminateProcessGroup(process *os.Process, gracePeriod time.Duration) {
+	pgid := -process.Pid
+
+	if err := syscall.Kill(pgid, syscall.SIGTERM); err != nil {
+		if errors.Is(err, syscall.ESRCH) {
+			return
+		}
+
+		_ = process.Kill()
+
+		return
+	}
+
+	time.AfterFunc(gracePeriod, func() {
+		_ = syscall.Kill(pgid, syscall.SIGKILL)
+	})
+}
@@ -0,0 +1,37 @@
+//go:build windows
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"os"
+	"os/exec"
+	"time"
+)
+
+// terminationGracePeriod is unused on Windows, where processes are killed
+// immediately.
+var terminationGracePeriod time.Duration
+
+// setProcessGroup is a no-op on Windows.
+func setProcessGroup(*exec.Cmd) {}
+
+// terminateProcessGroup kills the provided process on Windows.
+func terminateProcessGroup(process *os.Process, _ time.Duration) {
+	_ = process.Kill()
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// verifyRlimitNofile checks if the provided limit can be applied to a child
+// of the current process.
+func verifyRlimitNofile(limit *rlimit) error {
+	if limit.soft > limit.hard {
+		return fmt.Errorf(
+			"soft limit %d exceeds hard limit %d", limit.soft, limit.hard,
+		)
+	}
+
+	current := &unix.Rlimit{}
+	if err := unix.Getrlimit(unix.RLIMIT_NOFILE, current); err != nil {
+		return fmt.Errorf("get current limit: %w", err)
+	}
+
+	if limit.hard > current.Max {
+		return fmt.Errorf(
+			"hard limit %d exceeds the limit of the current process (%d)",
+			limit.hard, current.Max,
+		)
+	}
+
+	return nil
+}
+
+// setRlimitNofile applies the limit to the process with the provided pid.
+func setRlimitNofile(pid int, limit *rlimit) error {
+	return unix.Prlimit(
+		pid, unix.RLIMIT_NOFILE, &unix.Rlimit{Cur: limit.soft, Max: limit.hard}, nil,
+	)
+}
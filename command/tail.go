@@ -0,0 +1,106 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+// ringBuffer keeps the most recent lines written to it.
+type ringBuffer struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+	full  bool
+}
+
+// newRingBuffer creates a new ring buffer keeping up to size lines.
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{lines: make([]string, size)}
+}
+
+// add appends a line to the buffer and drops the oldest one if required.
+func (r *ringBuffer) add(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lines[r.next] = line
+	r.next = (r.next + 1) % len(r.lines)
+
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// String returns the buffered lines in order, separated by newlines.
+func (r *ringBuffer) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return strings.Join(r.lines[:r.next], "\n")
+	}
+
+	return strings.Join(append(r.lines[r.next:], r.lines[:r.next]...), "\n")
+}
+
+// maxLineLength is the maximum number of bytes a lineWriter buffers for a
+// single line. Longer lines, for example progress bars using carriage
+// returns or binary output, get split into lines of this length.
+const maxLineLength = 64 * 1024
+
+// lineWriter is an io.Writer splitting its input into lines, which get passed
+// to the add function, for example of a ring buffer. Every output stream uses
+// its own writer to not mix up partial lines when they share the same target.
+type lineWriter struct {
+	add     func(line string)
+	partial []byte
+}
+
+// Write passes all complete lines of p to the add function.
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			if len(w.partial) < maxLineLength {
+				break
+			}
+
+			w.add(string(w.partial[:maxLineLength]))
+			w.partial = w.partial[maxLineLength:]
+
+			continue
+		}
+
+		w.add(string(w.partial[:i]))
+		w.partial = w.partial[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// flush passes the remaining partial line to the add function.
+func (w *lineWriter) flush() {
+	if len(w.partial) > 0 {
+		w.add(string(w.partial))
+		w.partial = nil
+	}
+}
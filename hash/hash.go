@@ -26,8 +26,14 @@ import (
 	"hash"
 	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
 )
 
 // SHA512ForFile returns the hex-encoded sha512 hash for the provided filename.
@@ -46,15 +52,68 @@ func SHA1ForFile(filename string) (string, error) {
 	return ForFile(filename, sha1.New()) //nolint: gosec
 }
 
+// SHA3_256ForFile returns the hex-encoded sha3-256 hash for the provided
+// filename.
+func SHA3_256ForFile(filename string) (string, error) { //nolint:revive,stylecheck // name follows the algorithm
+	return ForFile(filename, sha3.New256())
+}
+
+// BLAKE2b256ForFile returns the hex-encoded blake2b-256 hash for the provided
+// filename.
+func BLAKE2b256ForFile(filename string) (string, error) {
+	hasher, err := blake2b.New256(nil)
+	if err != nil {
+		return "", fmt.Errorf("create blake2b-256 hasher: %w", err)
+	}
+
+	return ForFile(filename, hasher)
+}
+
+// NewHasher returns a new hasher for the provided algorithm name. Supported
+// algorithms are "sha1", "sha256", "sha512", "sha3-256" and "blake2b-256",
+// while the name is matched case-insensitively.
+func NewHasher(algorithm string) (hash.Hash, error) {
+	switch strings.ToLower(algorithm) {
+	case "sha1":
+		return sha1.New(), nil //nolint: gosec
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	case "sha3-256":
+		return sha3.New256(), nil
+	case "blake2b-256":
+		hasher, err := blake2b.New256(nil)
+		if err != nil {
+			return nil, fmt.Errorf("create blake2b-256 hasher: %w", err)
+		}
+
+		return hasher, nil
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm: %q", algorithm)
+	}
+}
+
 // ForFile returns the hex-encoded hash for the provided filename and hasher.
 func ForFile(filename string, hasher hash.Hash) (string, error) {
+	digest, err := ForFileRaw(filename, hasher)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(digest), nil
+}
+
+// ForFileRaw returns the raw hash digest for the provided filename and hasher,
+// which is useful when the digest has to be embedded into binary output.
+func ForFileRaw(filename string, hasher hash.Hash) ([]byte, error) {
 	if hasher == nil {
-		return "", errors.New("provided hasher is nil")
+		return nil, errors.New("provided hasher is nil")
 	}
 
 	f, err := os.Open(filename)
 	if err != nil {
-		return "", fmt.Errorf("open file %s: %w", filename, err)
+		return nil, fmt.Errorf("open file %s: %w", filename, err)
 	}
 
 	defer func() {
@@ -63,10 +122,208 @@ func ForFile(filename string, hasher hash.Hash) (string, error) {
 		}
 	}()
 
+	digest, err := sumReader(f, hasher)
+	if err != nil {
+		return nil, fmt.Errorf("hash file %s: %w", filename, err)
+	}
+
+	return digest, nil
+}
+
+// ForFileMulti returns the hex-encoded hashes for the provided filename, keyed
+// the same way as the provided hashers. The file is read only once, while the
+// data is written to all hashers.
+func ForFileMulti(filename string, hashers map[string]hash.Hash) (map[string]string, error) {
+	if len(hashers) == 0 {
+		return nil, errors.New("no hashers provided")
+	}
+
+	writers := make([]io.Writer, 0, len(hashers))
+
+	for key, hasher := range hashers {
+		if hasher == nil {
+			return nil, fmt.Errorf("provided hasher %q is nil", key)
+		}
+
+		hasher.Reset()
+		writers = append(writers, hasher)
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("open file %s: %w", filename, err)
+	}
+
+	defer func() {
+		if err := f.Close(); err != nil {
+			logrus.Warnf("Unable to close file %q: %v", filename, err)
+		}
+	}()
+
+	if _, err := io.Copy(io.MultiWriter(writers...), f); err != nil {
+		return nil, fmt.Errorf("hash file %s: %w", filename, err)
+	}
+
+	res := make(map[string]string, len(hashers))
+	for key, hasher := range hashers {
+		res[key] = hex.EncodeToString(hasher.Sum(nil))
+	}
+
+	return res, nil
+}
+
+// SHA256ForReader returns the hex-encoded sha256 hash for the data of the
+// provided reader.
+func SHA256ForReader(r io.Reader) (string, error) {
+	return ForReader(r, sha256.New())
+}
+
+// SHA512ForReader returns the hex-encoded sha512 hash for the data of the
+// provided reader.
+func SHA512ForReader(r io.Reader) (string, error) {
+	return ForReader(r, sha512.New())
+}
+
+// SHA256ForBytes returns the hex-encoded sha256 hash for the provided data.
+func SHA256ForBytes(data []byte) string {
+	digest := sha256.Sum256(data)
+
+	return hex.EncodeToString(digest[:])
+}
+
+// ForReader returns the hex-encoded hash for the data of the provided reader
+// and hasher. The reader is consumed until EOF.
+func ForReader(r io.Reader, hasher hash.Hash) (string, error) {
+	if hasher == nil {
+		return "", errors.New("provided hasher is nil")
+	}
+
+	digest, err := sumReader(r, hasher)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(digest), nil
+}
+
+// sumReader resets the hasher and returns the raw digest of the data of the
+// provided reader.
+func sumReader(r io.Reader, hasher hash.Hash) ([]byte, error) {
 	hasher.Reset()
 
-	if _, err := io.Copy(hasher, f); err != nil {
-		return "", fmt.Errorf("hash file %s: %w", filename, err)
+	if _, err := io.Copy(hasher, r); err != nil {
+		return nil, fmt.Errorf("read data: %w", err)
+	}
+
+	return hasher.Sum(nil), nil
+}
+
+// ForGlob returns the hex-encoded hashes for all files matching the provided
+// glob pattern, keyed by their path. The pattern syntax is the same as for
+// filepath.Match. A fresh hasher is constructed for every file by using the
+// provided factory. Directories matching the pattern are skipped.
+func ForGlob(pattern string, hasher func() hash.Hash) (map[string]string, error) {
+	if hasher == nil {
+		return nil, errors.New("provided hasher factory is nil")
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("expand glob pattern %s: %w", pattern, err)
+	}
+
+	res := make(map[string]string, len(matches))
+
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil {
+			return nil, fmt.Errorf("stat %s: %w", match, err)
+		}
+
+		if info.IsDir() {
+			continue
+		}
+
+		digest, err := ForFile(match, hasher())
+		if err != nil {
+			return nil, err
+		}
+
+		res[match] = digest
+	}
+
+	return res, nil
+}
+
+// SHA256ForDirectory returns a deterministic hex-encoded sha256 digest for the
+// whole directory tree at root. Every file contributes its path relative to
+// root together with its own sha256 digest, in sorted path order, so the
+// result does not depend on the filesystem iteration order. Symlinks are not
+// followed but hashed by their target string. Files whose path matches any of
+// the provided regular expressions are excluded. Other special files, like
+// named pipes, sockets or devices, result in an error unless excluded.
+func SHA256ForDirectory(root string, excludes ...*regexp.Regexp) (string, error) {
+	type entry struct{ path, kind, digest string }
+
+	entries := []entry{}
+
+	if err := filepath.Walk(root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		for _, re := range excludes {
+			if re != nil && re.MatchString(filePath) {
+				logrus.Tracef("Excluding: %s", filePath)
+
+				return nil
+			}
+		}
+
+		rel, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return fmt.Errorf("get relative path of %s: %w", filePath, err)
+		}
+
+		e := entry{path: filepath.ToSlash(rel), kind: "file"}
+
+		switch {
+		case info.Mode()&os.ModeSymlink == os.ModeSymlink:
+			link, err := os.Readlink(filePath)
+			if err != nil {
+				return fmt.Errorf("read file link of %s: %w", filePath, err)
+			}
+
+			e.kind = "symlink"
+			e.digest = SHA256ForBytes([]byte(filepath.ToSlash(link)))
+		case !info.Mode().IsRegular():
+			// Reading a named pipe or device may block forever
+			return fmt.Errorf("unsupported file type %s of %s", info.Mode().Type(), filePath)
+		default:
+			e.digest, err = SHA256ForFile(filePath)
+			if err != nil {
+				return err
+			}
+		}
+
+		entries = append(entries, e)
+
+		return nil
+	}); err != nil {
+		return "", fmt.Errorf("walking tree in %q: %w", root, err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].path < entries[j].path
+	})
+
+	hasher := sha256.New()
+	for _, e := range entries {
+		fmt.Fprintf(hasher, "%s %s %d:%s\n", e.digest, e.kind, len(e.path), e.path)
 	}
 
 	return hex.EncodeToString(hasher.Sum(nil)), nil
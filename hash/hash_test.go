@@ -19,9 +19,18 @@ package hash_test
 import (
 	"crypto/sha1" //nolint: gosec
 	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
 	"hash"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/require"
 
@@ -141,6 +150,60 @@ func TestSHA1ForFile(t *testing.T) {
 	}
 }
 
+func TestSHA3_256ForFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(filename, []byte("test"), 0o600))
+
+	res, err := kHash.SHA3_256ForFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "36f028580bb02cc8272a9a020f4200e346e276ae664e45ee80745574e2f5ab80", res)
+
+	_, err = kHash.SHA3_256ForFile("")
+	require.Error(t, err)
+}
+
+func TestBLAKE2b256ForFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(filename, []byte("test"), 0o600))
+
+	res, err := kHash.BLAKE2b256ForFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "928b20366943e2afd11ebc0eae2e53a93bf177a4fcf35bcc64d503704e65e202", res)
+
+	_, err = kHash.BLAKE2b256ForFile("")
+	require.Error(t, err)
+}
+
+func TestNewHasher(t *testing.T) {
+	for _, tc := range []struct {
+		algorithm   string
+		expected    string
+		shouldError bool
+	}{
+		{algorithm: "sha1", expected: "a94a8fe5ccb19ba61c4c0873d391e987982fbbd3"},
+		{algorithm: "sha256", expected: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"},
+		{algorithm: "SHA512", expected: "ee26b0dd4af7e749aa1a8ee3c10ae9923f618980772e473f8819a5d4940e0db27ac185f8a0e1d5f84f88bc887fd67b143732c304cc5fa9ad8e6f57f50028a8ff"},
+		{algorithm: "sha3-256", expected: "36f028580bb02cc8272a9a020f4200e346e276ae664e45ee80745574e2f5ab80"},
+		{algorithm: "BLAKE2b-256", expected: "928b20366943e2afd11ebc0eae2e53a93bf177a4fcf35bcc64d503704e65e202"},
+		{algorithm: "md5", shouldError: true},
+		{algorithm: "", shouldError: true},
+	} {
+		hasher, err := kHash.NewHasher(tc.algorithm)
+
+		if tc.shouldError {
+			require.Error(t, err)
+
+			continue
+		}
+
+		require.NoError(t, err)
+
+		res, err := kHash.ForReader(strings.NewReader("test"), hasher)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, res, tc.algorithm)
+	}
+}
+
 func TestForFile(t *testing.T) {
 	for _, tc := range []struct {
 		prepare     func() (string, hash.Hash)
@@ -185,3 +248,185 @@ func TestForFile(t *testing.T) {
 		}
 	}
 }
+
+func TestForFileRaw(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(filename, []byte("test"), 0o600))
+
+	for _, newHasher := range []func() hash.Hash{sha1.New, sha256.New, sha512.New} {
+		raw, err := kHash.ForFileRaw(filename, newHasher())
+		require.NoError(t, err)
+
+		digest, err := kHash.ForFile(filename, newHasher())
+		require.NoError(t, err)
+		require.Equal(t, digest, hex.EncodeToString(raw))
+	}
+
+	_, err := kHash.ForFileRaw(filename, nil)
+	require.Error(t, err)
+
+	_, err = kHash.ForFileRaw("", sha256.New())
+	require.Error(t, err)
+}
+
+func TestForReader(t *testing.T) {
+	const content = "test"
+
+	filename := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+
+	sha256File, err := kHash.SHA256ForFile(filename)
+	require.NoError(t, err)
+
+	sha256Reader, err := kHash.SHA256ForReader(strings.NewReader(content))
+	require.NoError(t, err)
+	require.Equal(t, sha256File, sha256Reader)
+	require.Equal(t, sha256File, kHash.SHA256ForBytes([]byte(content)))
+
+	sha512File, err := kHash.SHA512ForFile(filename)
+	require.NoError(t, err)
+
+	sha512Reader, err := kHash.SHA512ForReader(strings.NewReader(content))
+	require.NoError(t, err)
+	require.Equal(t, sha512File, sha512Reader)
+
+	_, err = kHash.ForReader(strings.NewReader(content), nil)
+	require.Error(t, err)
+
+	_, err = kHash.ForReader(iotest.ErrReader(errors.New("test")), sha256.New())
+	require.Error(t, err)
+}
+
+func TestForFileMulti(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(filename, []byte("test"), 0o600))
+
+	res, err := kHash.ForFileMulti(filename, map[string]hash.Hash{
+		"sha256": sha256.New(),
+		"sha512": sha512.New(),
+	})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+
+	sha256Digest, err := kHash.SHA256ForFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, sha256Digest, res["sha256"])
+
+	sha512Digest, err := kHash.SHA512ForFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, sha512Digest, res["sha512"])
+
+	_, err = kHash.ForFileMulti(filename, map[string]hash.Hash{})
+	require.Error(t, err)
+
+	_, err = kHash.ForFileMulti(filename, map[string]hash.Hash{"nil": nil})
+	require.Error(t, err)
+
+	_, err = kHash.ForFileMulti("", map[string]hash.Hash{"sha256": sha256.New()})
+	require.Error(t, err)
+}
+
+func TestForGlob(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"a.tar.gz", "b.tar.gz", "c.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("test"), 0o600))
+	}
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "d.tar.gz"), 0o755))
+
+	const expected = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	res, err := kHash.ForGlob(filepath.Join(dir, "*.tar.gz"), sha256.New)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		filepath.Join(dir, "a.tar.gz"): expected,
+		filepath.Join(dir, "b.tar.gz"): expected,
+	}, res)
+
+	res, err = kHash.ForGlob(filepath.Join(dir, "*.zip"), sha256.New)
+	require.NoError(t, err)
+	require.Empty(t, res)
+
+	_, err = kHash.ForGlob("[", sha256.New)
+	require.Error(t, err)
+
+	_, err = kHash.ForGlob(filepath.Join(dir, "*"), nil)
+	require.Error(t, err)
+}
+
+func TestSHA256ForDirectory(t *testing.T) {
+	prepare := func(content string) string {
+		dir := t.TempDir()
+
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "a", "b"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "a", "b", "file"), []byte(content), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "a", "file"), []byte("test"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "file.log"), []byte("log"), 0o600))
+		require.NoError(t, os.Symlink(filepath.Join("b", "file"), filepath.Join(dir, "a", "link")))
+
+		return dir
+	}
+
+	dir1 := prepare("test")
+	dir2 := prepare("test")
+
+	digest1, err := kHash.SHA256ForDirectory(dir1)
+	require.NoError(t, err)
+	require.Len(t, digest1, 64)
+
+	digest2, err := kHash.SHA256ForDirectory(dir2)
+	require.NoError(t, err)
+	require.Equal(t, digest1, digest2)
+
+	// A single byte change alters the digest
+	dir3 := prepare("tesT")
+	digest3, err := kHash.SHA256ForDirectory(dir3)
+	require.NoError(t, err)
+	require.NotEqual(t, digest1, digest3)
+
+	// Changing the symlink target alters the digest
+	require.NoError(t, os.Remove(filepath.Join(dir2, "a", "link")))
+	require.NoError(t, os.Symlink("file", filepath.Join(dir2, "a", "link")))
+	digest2, err = kHash.SHA256ForDirectory(dir2)
+	require.NoError(t, err)
+	require.NotEqual(t, digest1, digest2)
+
+	// Excluded files do not contribute to the digest
+	require.NoError(t, os.WriteFile(filepath.Join(dir3, "file.log"), []byte("changed"), 0o600))
+	excludes := regexp.MustCompile(`\.log$`)
+
+	digest1, err = kHash.SHA256ForDirectory(dir1, excludes)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir3, "a", "b", "file"), []byte("test"), 0o600))
+	digest3, err = kHash.SHA256ForDirectory(dir3, excludes)
+	require.NoError(t, err)
+	require.Equal(t, digest1, digest3)
+
+	_, err = kHash.SHA256ForDirectory(filepath.Join(dir1, "missing"))
+	require.Error(t, err)
+}
+
+func TestSHA256ForDirectorySpecialFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("named pipes are not supported on windows")
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file"), []byte("test"), 0o600))
+
+	digest, err := kHash.SHA256ForDirectory(dir)
+	require.NoError(t, err)
+
+	fifo := filepath.Join(dir, "fifo")
+	require.NoError(t, exec.Command("mkfifo", fifo).Run())
+
+	_, err = kHash.SHA256ForDirectory(dir)
+	require.ErrorContains(t, err, "unsupported file type")
+	require.ErrorContains(t, err, fifo)
+
+	excluded, err := kHash.SHA256ForDirectory(dir, regexp.MustCompile(`fifo$`))
+	require.NoError(t, err)
+	require.Equal(t, digest, excluded)
+}
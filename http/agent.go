@@ -18,24 +18,86 @@ package http
 
 import (
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"math"
+	"mime"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/avast/retry-go/v4"
 	"github.com/nozzle/throttler"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/time/rate"
+
+	"sigs.k8s.io/release-utils/internal/redact"
+	"sigs.k8s.io/release-utils/tar"
 )
 
 const (
 	defaultPostContentType = "application/octet-stream"
+
+	// contentTypeSnippetSize is the maximum number of body bytes included in
+	// content type mismatch errors.
+	contentTypeSnippetSize = 256
 )
 
+// ErrUnexpectedContentType is returned if the content type of a response
+// does not match the one set via WithExpectedContentType.
+var ErrUnexpectedContentType = errors.New("unexpected content type")
+
+// ErrChecksumMismatch is returned if the SHA256 digest of a download does not
+// match the expected one.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ErrBodyTooLarge is returned if a response body exceeds the size set via
+// WithMaxBodySize.
+var ErrBodyTooLarge = errors.New("response body too large")
+
+// HTTPError is returned if FailOnHTTPError is set and the status code of a
+// response is not in the 200s. It can be inspected by using errors.As.
+type HTTPError struct {
+	StatusCode int    // HTTP status code of the response
+	Status     string // HTTP status of the response, like "404 Not Found"
+	URL        string // URL of the request
+}
+
+// Error returns the error message of the HTTPError.
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP error %s for %s", e.Status, e.URL)
+}
+
+// newHTTPError returns an HTTPError for the response. Passwords and the
+// values of sensitive query parameters get removed from the URL.
+func newHTTPError(response *http.Response) *HTTPError {
+	httpErr := &HTTPError{StatusCode: response.StatusCode, Status: response.Status}
+	if response.Request != nil && response.Request.URL != nil {
+		httpErr.URL = redact.URL(response.Request.URL.String())
+	}
+
+	return httpErr
+}
+
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
 //go:generate /usr/bin/env bash -c "cat ../scripts/boilerplate/boilerplate.generatego.txt httpfakes/fake_agent_implementation.go > httpfakes/_fake_agent_implementation.go && mv httpfakes/_fake_agent_implementation.go httpfakes/fake_agent_implementation.go"
 
@@ -43,6 +105,16 @@ const (
 type Agent struct {
 	options *agentOptions
 	AgentImplementation
+
+	transportMu sync.Mutex
+	transport   *http.Transport
+
+	limiterMu sync.Mutex
+	limiter   *rate.Limiter
+
+	// randInt64N returns a random number in [0, n) and defaults to
+	// rand.Int64N. It can be replaced for deterministic tests.
+	randInt64N func(n int64) int64
 }
 
 // AgentImplementation is the actual implementation of the http calls
@@ -52,19 +124,71 @@ type AgentImplementation interface {
 	SendPostRequest(*http.Client, string, []byte, string) (*http.Response, error)
 	SendGetRequest(*http.Client, string) (*http.Response, error)
 	SendHeadRequest(*http.Client, string) (*http.Response, error)
+	SendPutRequest(*http.Client, string, []byte, string) (*http.Response, error)
+	SendDeleteRequest(*http.Client, string) (*http.Response, error)
+	SendPostReaderRequest(*http.Client, string, io.Reader, int64, string) (*http.Response, error)
+}
+
+// AgentContextImplementation is an optional extension of the
+// AgentImplementation, which allows to bind the requests to a context. The
+// context-aware methods of the Agent will fall back to the AgentImplementation
+// methods if the implementation does not satisfy this interface.
+type AgentContextImplementation interface {
+	SendPostRequestWithContext(context.Context, *http.Client, string, []byte, string) (*http.Response, error)
+	SendGetRequestWithContext(context.Context, *http.Client, string) (*http.Response, error)
+	SendHeadRequestWithContext(context.Context, *http.Client, string) (*http.Response, error)
+	SendPutRequestWithContext(context.Context, *http.Client, string, []byte, string) (*http.Response, error)
+	SendDeleteRequestWithContext(context.Context, *http.Client, string) (*http.Response, error)
+	SendPostReaderRequestWithContext(
+		context.Context, *http.Client, string, io.Reader, int64, string,
+	) (*http.Response, error)
+}
+
+// AgentStreamImplementation is an optional extension of the
+// AgentImplementation, which allows to stream the data of POST requests from a
+// seekable reader of the provided size. It is used for data exceeding the
+// threshold set via WithStreamThreshold.
+type AgentStreamImplementation interface {
+	SendPostRequestStream(context.Context, *http.Client, string, io.ReadSeeker, int64, string) (*http.Response, error)
 }
 
 type defaultAgentImplementation struct{}
 
 // agentOptions has the configurable bits of the agent.
 type agentOptions struct {
-	FailOnHTTPError bool          // Set to true to fail on HTTP Status > 299
-	Retries         uint          // Number of times to retry when errors happen
-	Timeout         time.Duration // Timeout when fetching URLs
-	WaitTime        time.Duration // Initial wait time for backing off on retry
-	MaxWaitTime     time.Duration // Max waiting time when backing off on retry
-	PostContentType string        // Content type to send when posting data
-	MaxParallel     uint          // Maximum number of parallel requests when requesting groups
+	FailOnHTTPError      bool              // Set to true to fail on HTTP Status > 299
+	Retries              uint              // Total number of attempts when errors happen, zero behaves like one
+	Timeout              time.Duration     // Timeout when fetching URLs
+	WaitTime             time.Duration     // Initial wait time for backing off on retry
+	MaxWaitTime          time.Duration     // Max waiting time when backing off on retry
+	PostContentType      string            // Content type to send when posting or putting data
+	MaxParallel          uint              // Maximum number of parallel requests when requesting groups
+	LogAsCurl            bool              // Log each request as curl command on debug level
+	Resolver             *net.Resolver     // Custom DNS resolver to be used when dialing
+	HostOverrides        map[string]string // Static host to address overrides used when dialing
+	MaxConnsPerHost      int               // Maximum number of connections per host, zero means no limit
+	Headers              http.Header       // Additional headers sent with every request
+	Accept               string            // Value of the Accept header, takes precedence over Headers
+	AcceptLanguage       string            // Value of the Accept-Language header, takes precedence over Headers
+	BasicAuthUser        string            // User name for basic authentication
+	BasicAuthPassword    string            // Password for basic authentication
+	BearerToken          string            // Token for bearer authentication, takes precedence over basic authentication
+	RetryableStatusCodes map[int]struct{}  // Status codes to retry on, nil for the defaults
+	ExpectedContentType  string            // Expected response content type, empty to accept any
+	RespectRetryAfter    bool              // Use the Retry-After response header as retry delay
+	Jitter               bool              // Randomize the exponential backoff delay
+	Client               *http.Client      // Custom client used for all requests
+	Transport            http.RoundTripper // Custom transport used for all requests
+	StreamThreshold      int64             // POST data size above which it is streamed from a temporary file, zero to disable
+	MaxBodySize          int64             // Maximum size of response bodies in bytes, zero means no limit
+	RateLimit            float64           // Maximum number of requests per second, zero means no limit
+	AutoDecompress       bool              // Decompress response bodies based on the Content-Encoding header
+	URLRedaction         bool              // Redact sensitive query parameters and passwords from logged URLs
+	MinTLSVersion        uint16            // Minimum TLS version, like tls.VersionTLS12
+	MaxTLSVersion        uint16            // Maximum TLS version, zero means the highest supported one
+
+	ProgressCallback      func(written, total int64)            // Reports the progress of reading responses
+	GroupProgressCallback func(index int, written, total int64) // Reports the progress of reading group responses
 }
 
 // String returns a string representation of the options.
@@ -75,21 +199,28 @@ func (ao *agentOptions) String() string {
 	)
 }
 
-var defaultAgentOptions = &agentOptions{
-	FailOnHTTPError: true,
-	Retries:         3,
-	Timeout:         3 * time.Second,
-	WaitTime:        2 * time.Second,
-	MaxWaitTime:     60 * time.Second,
-	PostContentType: defaultPostContentType,
-	MaxParallel:     5,
+// newDefaultAgentOptions returns a freshly allocated set of default options.
+func newDefaultAgentOptions() *agentOptions {
+	return &agentOptions{
+		FailOnHTTPError:   true,
+		Retries:           3,
+		Timeout:           3 * time.Second,
+		WaitTime:          2 * time.Second,
+		MaxWaitTime:       60 * time.Second,
+		PostContentType:   defaultPostContentType,
+		MaxParallel:       5,
+		RespectRetryAfter: true,
+		Jitter:            true,
+		URLRedaction:      true,
+		MinTLSVersion:     tls.VersionTLS12,
+	}
 }
 
 // NewAgent return a new agent with default options.
 func NewAgent() *Agent {
 	return &Agent{
 		AgentImplementation: &defaultAgentImplementation{},
-		options:             defaultAgentOptions,
+		options:             newDefaultAgentOptions(),
 	}
 }
 
@@ -105,13 +236,24 @@ func (a *Agent) WithTimeout(timeout time.Duration) *Agent {
 	return a
 }
 
-// WithRetries sets the number of times we'll attempt to fetch the URL.
+// WithRetries sets the number of times we'll attempt to fetch the URL. The
+// value is the total number of attempts including the first one, not the
+// number of additional retries: 3 results in up to 3 requests, while 0 and 1
+// both result in a single request. Use WithNoRetries to make that explicit.
 func (a *Agent) WithRetries(retries uint) *Agent {
 	a.options.Retries = retries
 
 	return a
 }
 
+// WithNoRetries disables retries, which means that every request is attempted
+// exactly once.
+func (a *Agent) WithNoRetries() *Agent {
+	a.options.Retries = 1
+
+	return a
+}
+
 // WithWaitTime sets the initial wait time for request retry.
 func (a *Agent) WithWaitTime(time time.Duration) *Agent {
 	a.options.WaitTime = time
@@ -141,233 +283,1690 @@ func (a *Agent) WithMaxParallel(workers int) *Agent {
 	return a
 }
 
-// Client return an net/http client preconfigured with the agent options.
-func (a *Agent) Client() *http.Client {
-	return &http.Client{
-		Timeout: a.options.Timeout,
-	}
+// WithLogAsCurl enables logging every request as an equivalent curl command
+// on debug level. Sensitive data like authentication headers will be redacted.
+func (a *Agent) WithLogAsCurl(flag bool) *Agent {
+	a.options.LogAsCurl = flag
+
+	return a
 }
 
-// Get returns the body a GET request.
-func (a *Agent) Get(url string) (content []byte, err error) {
-	request, err := a.GetRequest(url)
-	if err != nil {
-		return nil, fmt.Errorf("getting GET request: %w", err)
+// WithResolver sets a custom DNS resolver used to look up hostnames.
+func (a *Agent) WithResolver(resolver *net.Resolver) *Agent {
+	a.options.Resolver = resolver
+	a.resetTransport()
+
+	return a
+}
+
+// WithHostOverride statically resolves the provided host to addr, which can
+// be either a plain IP address or hostname, or one including a port.
+func (a *Agent) WithHostOverride(host, addr string) *Agent {
+	if a.options.HostOverrides == nil {
+		a.options.HostOverrides = map[string]string{}
 	}
-	defer request.Body.Close()
 
-	return a.readResponseToByteArray(request)
+	a.options.HostOverrides[host] = addr
+	a.resetTransport()
+
+	return a
 }
 
-// GetRequest sends a GET request to a URL and returns the request and response.
-func (a *Agent) GetRequest(url string) (response *http.Response, err error) {
-	logrus.Debugf("Sending GET request to %s", url)
+// WithMaxConnsPerHost limits the total number of connections per host,
+// including connections in the dialing, active, and idle states. Contrary to
+// WithMaxParallel, which limits the number of parallel requests of groups,
+// this option applies to all requests of the agent. Zero means no limit.
+func (a *Agent) WithMaxConnsPerHost(n int) *Agent {
+	a.options.MaxConnsPerHost = n
+	a.resetTransport()
 
-	return a.retryRequest(func() (*http.Response, error) {
-		return a.AgentImplementation.SendGetRequest(a.Client(), url)
-	})
+	return a
 }
 
-// Post returns the body of a POST request.
-func (a *Agent) Post(url string, postData []byte) (content []byte, err error) {
-	response, err := a.PostRequest(url, postData)
-	if err != nil {
-		return nil, fmt.Errorf("getting post request: %w", err)
-	}
-	defer response.Body.Close()
+// WithMinTLSVersion sets the minimum TLS version used for HTTPS requests, for
+// example tls.VersionTLS13, which prevents a downgrade to older versions on
+// misconfigured servers. Defaults to tls.VersionTLS12. The option does not
+// apply to a custom client or transport set via WithClient or WithTransport.
+func (a *Agent) WithMinTLSVersion(version uint16) *Agent {
+	a.options.MinTLSVersion = version
+	a.resetTransport()
 
-	return a.readResponseToByteArray(response)
+	return a
 }
 
-// PostRequest sends the postData in a POST request to a URL and returns the request object.
-func (a *Agent) PostRequest(url string, postData []byte) (response *http.Response, err error) {
-	logrus.Debugf("Sending POST request to %s", url)
+// WithMaxTLSVersion sets the maximum TLS version used for HTTPS requests,
+// which is mostly useful for testing against servers with specific
+// capabilities. Zero means the highest version supported by Go. Like
+// WithMinTLSVersion, it does not apply to a custom client or transport.
+func (a *Agent) WithMaxTLSVersion(version uint16) *Agent {
+	a.options.MaxTLSVersion = version
+	a.resetTransport()
 
-	return a.retryRequest(func() (*http.Response, error) {
-		return a.AgentImplementation.SendPostRequest(a.Client(), url, postData, a.options.PostContentType)
-	})
+	return a
 }
 
-func (a *Agent) retryRequest(do func() (*http.Response, error)) (response *http.Response, err error) {
-	err = retry.Do(func() error {
-		//nolint:bodyclose // The API consumer should close the body
-		response, err = do()
-		if retryErr := shouldRetry(response, err); retryErr != nil {
-			return retryErr
-		}
+// WithHeader adds a header which is sent along with every request. Calling
+// it multiple times with the same key adds multiple values. The typed setters
+// like WithAccept take precedence over headers set by this method.
+func (a *Agent) WithHeader(key, value string) *Agent {
+	if a.options.Headers == nil {
+		a.options.Headers = http.Header{}
+	}
 
-		return nil
-	},
-		retry.Attempts(a.options.Retries),
-		retry.Delay(a.options.WaitTime),
-		retry.MaxDelay(a.options.MaxWaitTime),
-		retry.DelayType(retry.BackOffDelay),
-		retry.OnRetry(func(attempt uint, err error) {
-			logrus.Errorf("Unable to do request (attempt %d/%d): %v", attempt+1, a.options.Retries, err)
-		}),
-	)
+	a.options.Headers.Add(key, value)
 
-	return response, err
+	return a
 }
 
-func shouldRetry(resp *http.Response, err error) error {
-	urlErr := &url.Error{}
-	if err != nil && errors.As(err, &urlErr) {
-		return err
+// WithHeaders sets the provided headers, which are sent along with every
+// request. Contrary to WithHeader, existing values of the same keys get
+// replaced.
+func (a *Agent) WithHeaders(headers map[string]string) *Agent {
+	if a.options.Headers == nil {
+		a.options.Headers = http.Header{}
 	}
 
-	if resp.StatusCode == http.StatusTooManyRequests {
-		return fmt.Errorf("retry %d: %s", resp.StatusCode, resp.Status)
+	for key, value := range headers {
+		a.options.Headers.Set(key, value)
 	}
 
-	if resp.StatusCode == 0 || (resp.StatusCode >= 500 &&
-		resp.StatusCode != http.StatusNotImplemented) {
-		return fmt.Errorf("retry unexpected HTTP status %d: %s", resp.StatusCode, resp.Status)
-	}
+	return a
+}
 
-	return nil
+// WithAccept sets the Accept header sent along with every request.
+func (a *Agent) WithAccept(accept string) *Agent {
+	a.options.Accept = accept
+
+	return a
 }
 
-// Head returns the body of a HEAD request.
-func (a *Agent) Head(url string) (content []byte, err error) {
-	response, err := a.HeadRequest(url)
-	if err != nil {
-		return nil, fmt.Errorf("getting head request: %w", err)
+// WithAcceptLanguage sets the Accept-Language header sent along with every
+// request.
+func (a *Agent) WithAcceptLanguage(language string) *Agent {
+	a.options.AcceptLanguage = language
+
+	return a
+}
+
+// WithBasicAuth authenticates every request of the agent by using the
+// provided user name and password for basic authentication. It replaces any
+// token set via WithBearerToken. The credentials are redacted from logs and
+// returned errors.
+func (a *Agent) WithBasicAuth(user, pass string) *Agent {
+	a.options.BasicAuthUser = user
+	a.options.BasicAuthPassword = pass
+	a.options.BearerToken = ""
+
+	return a
+}
+
+// WithBearerToken authenticates every request of the agent by using the
+// provided bearer token. It replaces any credentials set via WithBasicAuth.
+// The token is redacted from logs and returned errors.
+func (a *Agent) WithBearerToken(token string) *Agent {
+	a.options.BearerToken = token
+	a.options.BasicAuthUser = ""
+	a.options.BasicAuthPassword = ""
+
+	return a
+}
+
+// WithContentType sets the Content-Type header used when posting or putting
+// data.
+func (a *Agent) WithContentType(contentType string) *Agent {
+	a.options.PostContentType = contentType
+
+	return a
+}
+
+// WithStreamThreshold sets the size in bytes above which POST data is written
+// to a temporary file and streamed from there instead of being sent from
+// memory. A threshold of zero, the default, disables streaming. Every attempt
+// uses a fresh temporary file, while the body has to be seekable to be re-read
+// when following redirects. Streaming only takes effect if the agent
+// implementation satisfies AgentStreamImplementation.
+func (a *Agent) WithStreamThreshold(n int64) *Agent {
+	a.options.StreamThreshold = n
+
+	return a
+}
+
+// WithMaxBodySize limits the size of response bodies read by the agent to n
+// bytes, which protects against misbehaving servers. Reading a larger body
+// fails with ErrBodyTooLarge, after the first n bytes have been consumed.
+// Zero, the default, means no limit.
+func (a *Agent) WithMaxBodySize(n int64) *Agent {
+	a.options.MaxBodySize = n
+
+	return a
+}
+
+// WithRateLimit limits the number of requests per second of the agent, which
+// applies to all requests including retries and groups. Requests wait for
+// their turn until their context is done. Zero, the default, means no limit.
+func (a *Agent) WithRateLimit(rps float64) *Agent {
+	a.options.RateLimit = rps
+	a.resetLimiter()
+
+	return a
+}
+
+// WithURLRedaction enables or disables the redaction of URLs before logging
+// them, which is enabled by default. This removes passwords as well as the
+// values of sensitive query parameters, like "token" or "access_token", from
+// the logged URLs. Credentials set via WithBasicAuth or WithBearerToken are
+// redacted regardless of this option.
+func (a *Agent) WithURLRedaction(enabled bool) *Agent {
+	a.options.URLRedaction = enabled
+
+	return a
+}
+
+// WithAutoDecompress enables or disables the decompression of gzip and
+// deflate encoded response bodies based on their Content-Encoding header. The
+// default transport only decompresses responses transparently if it added the
+// Accept-Encoding header itself, which is not the case if the header has been
+// set manually, for example via WithHeaders. Enabling this option makes the
+// behavior independent from the request headers. The maximum body size applies
+// to the decompressed body and progress callbacks receive an unknown total.
+func (a *Agent) WithAutoDecompress(enabled bool) *Agent {
+	a.options.AutoDecompress = enabled
+
+	return a
+}
+
+// WithProgressCallback sets a callback which is invoked for every chunk of a
+// response body read, for example to render a progress bar. It receives the
+// number of bytes written so far and the total size from the Content-Length
+// header, or -1 if unknown. Group requests report their progress via
+// WithGroupProgressCallback instead.
+func (a *Agent) WithProgressCallback(callback func(written, total int64)) *Agent {
+	a.options.ProgressCallback = callback
+
+	return a
+}
+
+// WithGroupProgressCallback sets a callback which behaves like the one of
+// WithProgressCallback, but gets invoked for group requests and DownloadAll
+// along with the index of the request. It may be called concurrently for
+// different indexes.
+func (a *Agent) WithGroupProgressCallback(callback func(index int, written, total int64)) *Agent {
+	a.options.GroupProgressCallback = callback
+
+	return a
+}
+
+// WithRespectRetryAfter determines if the delay of the Retry-After response
+// header is used instead of the exponential backoff when retrying GET, POST,
+// PUT and DELETE requests. The delay is still limited by the maximum wait
+// time. Enabled by default.
+func (a *Agent) WithRespectRetryAfter(flag bool) *Agent {
+	a.options.RespectRetryAfter = flag
+
+	return a
+}
+
+// WithJitter determines if the exponential backoff delay between retries gets
+// randomized (full jitter), which means that the actual delay is a random
+// duration between zero and the exponential one, limited by the maximum wait
+// time. This avoids parallel requests to retry in lockstep. Enabled by
+// default.
+func (a *Agent) WithJitter(flag bool) *Agent {
+	a.options.Jitter = flag
+
+	return a
+}
+
+// WithRetryableStatusCodes sets the HTTP status codes on which GET, POST, PUT,
+// DELETE and HEAD requests get retried. Network errors are always retried. If
+// not set, GET, POST, PUT and DELETE requests are retried on status 429 as well
+// as all 5xx except 501, while HEAD requests are only retried on errors.
+func (a *Agent) WithRetryableStatusCodes(codes ...int) *Agent {
+	a.options.RetryableStatusCodes = statusCodeSet(codes)
+
+	return a
+}
+
+// statusCodeSet converts the status codes into a set.
+func statusCodeSet(codes []int) map[int]struct{} {
+	set := make(map[int]struct{}, len(codes))
+	for _, code := range codes {
+		set[code] = struct{}{}
 	}
-	defer response.Body.Close()
 
-	return a.readResponseToByteArray(response)
+	return set
 }
 
-// HeadRequest sends a HEAD request to a URL and returns the request and response.
-func (a *Agent) HeadRequest(url string) (response *http.Response, err error) {
-	logrus.Debugf("Sending HEAD request to %s", url)
+// WithExpectedContentType makes reading successful responses fail with
+// ErrUnexpectedContentType if their Content-Type does not start with the
+// provided one. Parameters like the charset are ignored and the comparison is
+// case insensitive. An empty string accepts any content type.
+func (a *Agent) WithExpectedContentType(contentType string) *Agent {
+	a.options.ExpectedContentType = contentType
 
-	var try uint
+	return a
+}
 
-	for {
-		response, err = a.AgentImplementation.SendHeadRequest(a.Client(), url)
-		try++
+// WithClient sets the http client used for all requests, for example to
+// configure mutual TLS, a proxy or custom root certificates. The timeout
+// option is only applied if the client does not set its own timeout, while
+// all transport related options like WithTransport, WithResolver,
+// WithHostOverride or WithMaxConnsPerHost are ignored in favor of the
+// transport of the client.
+func (a *Agent) WithClient(client *http.Client) *Agent {
+	a.options.Client = client
 
-		if err == nil || try >= a.options.Retries {
-			return response, err
+	return a
+}
+
+// WithTransport sets the round tripper used for all requests. The transport
+// related options WithResolver, WithHostOverride and WithMaxConnsPerHost are
+// ignored if set. This option has no effect if a client is provided via
+// WithClient.
+func (a *Agent) WithTransport(transport http.RoundTripper) *Agent {
+	a.options.Transport = transport
+
+	return a
+}
+
+// Client return an net/http client preconfigured with the agent options. If
+// a client has been provided via WithClient, then it will be returned as is
+// unless the agent has to apply a timeout or headers to it, in which case a
+// shallow copy sharing the same transport gets returned.
+func (a *Agent) Client() *http.Client {
+	headers := a.options.headers()
+
+	if base := a.options.Client; base != nil {
+		if base.Timeout != 0 && len(headers) == 0 {
+			return base
 		}
-		// Do exponential backoff...
-		waitTime := math.Pow(2, float64(try))
-		//  ... but wait no more than 1 min
-		if waitTime > 60 {
-			waitTime = a.options.MaxWaitTime.Seconds()
+
+		client := *base
+		if client.Timeout == 0 {
+			client.Timeout = a.options.Timeout
 		}
 
-		logrus.Errorf(
-			"Error getting URL (will retry %d more times in %.0f secs): %s",
-			a.options.Retries-try, waitTime, err.Error(),
-		)
-		time.Sleep(time.Duration(waitTime) * time.Second)
+		return withHeaders(&client, headers)
 	}
-}
 
-// SendPostRequest sends the actual HTTP post to the server.
-func (impl *defaultAgentImplementation) SendPostRequest(
-	client *http.Client, url string, postData []byte, contentType string,
-) (response *http.Response, err error) {
-	if contentType == "" {
-		contentType = defaultPostContentType
+	client := &http.Client{
+		Timeout: a.options.Timeout,
 	}
 
-	response, err = client.Post(url, contentType, bytes.NewBuffer(postData))
-	if err != nil {
-		return response, fmt.Errorf("posting data to %s: %w", url, err)
+	if a.options.Transport != nil {
+		client.Transport = a.options.Transport
+	} else if transport := a.getTransport(); transport != nil {
+		client.Transport = transport
 	}
 
-	return response, nil
+	return withHeaders(client, headers)
 }
 
-// SendGetRequest performs the actual request.
-func (impl *defaultAgentImplementation) SendGetRequest(client *http.Client, url string) (
-	response *http.Response, err error,
-) {
-	response, err = client.Get(url)
-	if err != nil {
-		return response, fmt.Errorf("getting %s: %w", url, err)
+// withHeaders sets up the client to send the provided headers along with
+// every request.
+func withHeaders(client *http.Client, headers http.Header) *http.Client {
+	if len(headers) > 0 {
+		client.Transport = &headerRoundTripper{
+			headers: headers,
+			next:    client.Transport,
+		}
 	}
 
-	return response, nil
+	return client
 }
 
-// SendHeadRequest performs the actual request.
-func (impl *defaultAgentImplementation) SendHeadRequest(client *http.Client, url string) (
-	response *http.Response, err error,
-) {
-	response, err = client.Head(url)
-	if err != nil {
-		return response, fmt.Errorf("sending head request %s: %w", url, err)
+// headers returns all headers to be sent along with every request, where the
+// typed header options take precedence over the generic ones.
+func (ao *agentOptions) headers() http.Header {
+	headers := ao.Headers.Clone()
+	if headers == nil {
+		headers = http.Header{}
 	}
 
-	return response, nil
+	if ao.Accept != "" {
+		headers.Set("Accept", ao.Accept)
+	}
+
+	if ao.AcceptLanguage != "" {
+		headers.Set("Accept-Language", ao.AcceptLanguage)
+	}
+
+	if authorization := ao.authorization(); authorization != "" {
+		headers.Set("Authorization", authorization)
+	}
+
+	return headers
 }
 
-// readResponseToByteArray returns the contents of an http response as a byte array.
-func (a *Agent) readResponseToByteArray(response *http.Response) ([]byte, error) {
-	var b bytes.Buffer
-	if err := a.readResponse(response, &b); err != nil {
-		return nil, fmt.Errorf("reading array buffer: %w", err)
+// authorization returns the value of the Authorization header for the
+// configured credentials or an empty string if none are set.
+func (ao *agentOptions) authorization() string {
+	if ao.BearerToken != "" {
+		return "Bearer " + ao.BearerToken
 	}
 
-	return b.Bytes(), nil
+	if ao.BasicAuthUser != "" || ao.BasicAuthPassword != "" {
+		return "Basic " + base64.StdEncoding.EncodeToString(
+			[]byte(ao.BasicAuthUser+":"+ao.BasicAuthPassword),
+		)
+	}
+
+	return ""
 }
 
-// readResponse reads and interprets the response to an HTTP request to an io.Writer.
-// If the response status is < 200 or >= 300 and FailOnHTTPError is set, the function
-// will return an error.
-//
-// This function will close the response body reader.
-func (a *Agent) readResponse(response *http.Response, w io.Writer) (err error) {
-	// Read the response body
-	defer response.Body.Close()
+// regexpURLPassword matches the password of URLs with embedded user info.
+var regexpURLPassword = regexp.MustCompile(`(://[^:/@\s]*):[^/@\s]*@`)
 
-	if _, err := io.Copy(w, response.Body); err != nil {
-		return fmt.Errorf("reading response: %w", err)
+// redactURL removes sensitive data from the provided URL to be logged. If
+// URL redaction is enabled, passwords and the values of sensitive query
+// parameters like "token" get removed. The configured credentials are always
+// removed.
+func (ao *agentOptions) redactURL(rawURL string) string {
+	if ao.URLRedaction {
+		rawURL = redact.URL(rawURL)
 	}
 
-	// Check the https response code
-	if response.StatusCode < 200 || response.StatusCode >= 300 {
-		if a.options.FailOnHTTPError {
-			return fmt.Errorf(
-				"HTTP error %s for %s", response.Status, response.Request.URL,
-			)
+	return ao.redactCredentials(rawURL)
+}
+
+// redactCredentials removes the configured credentials as well as passwords
+// embedded in URLs from the provided string.
+func (ao *agentOptions) redactCredentials(s string) string {
+	s = regexpURLPassword.ReplaceAllString(redactData(s), "$1:"+redact.Sanitized+"@")
+
+	// The authorization value without its scheme covers the encoded basic
+	// authentication credentials as well.
+	_, encoded, _ := strings.Cut(ao.authorization(), " ")
+
+	for _, secret := range []string{encoded, ao.BearerToken, ao.BasicAuthPassword} {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redact.Sanitized)
 		}
+	}
 
-		logrus.Warnf("Got HTTP error but FailOnHTTPError not set: %s", response.Status)
+	return s
+}
+
+// redactedError is an error with credentials removed from its message, which
+// still allows to inspect the wrapped error.
+type redactedError struct {
+	msg string
+	err error
+}
+
+// Error implements the error interface.
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the original error.
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactError removes the credentials from the message of the provided
+// error. The error is returned as is if it does not contain any.
+func (ao *agentOptions) redactError(err error) error {
+	if err == nil {
+		return nil
 	}
 
-	return err
+	msg := ao.redactCredentials(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+
+	return &redactedError{msg: msg, err: err}
+}
+
+// redactURLError behaves like redactError, but also replaces all occurrences
+// of the provided URL in the message with its redacted form, which removes
+// the values of sensitive query parameters as well.
+func (ao *agentOptions) redactURLError(err error, rawURL string) error {
+	if err == nil {
+		return nil
+	}
+
+	// Passwords are already replaced within the message
+	msg := strings.ReplaceAll(
+		ao.redactCredentials(err.Error()), ao.redactCredentials(rawURL), ao.redactURL(rawURL),
+	)
+	if msg == err.Error() {
+		return err
+	}
+
+	return &redactedError{msg: msg, err: err}
+}
+
+// Get returns the body a GET request.
+func (a *Agent) Get(url string) (content []byte, err error) {
+	return a.GetWithContext(context.Background(), url)
+}
+
+// GetWithContext returns the body a GET request bound to the provided
+// context.
+func (a *Agent) GetWithContext(ctx context.Context, url string) (content []byte, err error) {
+	request, err := a.GetRequestWithContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("getting GET request: %w", err)
+	}
+	defer request.Body.Close()
+
+	return a.readResponseToByteArray(request, a.options.ProgressCallback)
+}
+
+// GetText returns the body of a GET request as UTF-8 string. The body is
+// decoded by using the charset parameter of the Content-Type response header,
+// while UTF-8 is assumed if no charset is specified.
+func (a *Agent) GetText(url string) (string, error) {
+	response, err := a.GetRequest(url)
+	if err != nil {
+		return "", fmt.Errorf("getting GET request: %w", err)
+	}
+	defer response.Body.Close()
+
+	content, err := a.readResponseToByteArray(response, a.options.ProgressCallback)
+	if err != nil {
+		return "", err
+	}
+
+	return decodeText(response.Header.Get("Content-Type"), content)
+}
+
+// GetJSONInto sends a GET request and unmarshals the JSON response into
+// target. The path navigates into the response before unmarshalling, where
+// its dot separated elements are either object keys or array indices, for
+// example "data.items.0.name". An empty path unmarshals the whole response.
+func (a *Agent) GetJSONInto(url string, target any, path string) error {
+	content, err := a.Get(url)
+	if err != nil {
+		return err
+	}
+
+	var doc any
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return fmt.Errorf("unmarshal JSON response: %w", err)
+	}
+
+	value, err := jsonPath(doc, path)
+	if err != nil {
+		return err
+	}
+
+	// Marshalling the selected value again allows to unmarshal into target
+	// as usual, including all custom unmarshallers.
+	selected, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal JSON path %q: %w", path, err)
+	}
+
+	if err := json.Unmarshal(selected, target); err != nil {
+		return fmt.Errorf("unmarshal JSON path %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// jsonPath returns the value of the dot separated path within the decoded
+// JSON document.
+func jsonPath(doc any, path string) (any, error) {
+	if path == "" {
+		return doc, nil
+	}
+
+	value := doc
+
+	for _, elem := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			next, ok := v[elem]
+			if !ok {
+				return nil, fmt.Errorf("JSON path %q: key %q not found", path, elem)
+			}
+
+			value = next
+
+		case []any:
+			i, err := strconv.Atoi(elem)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf(
+					"JSON path %q: invalid index %q for array of length %d", path, elem, len(v),
+				)
+			}
+
+			value = v[i]
+
+		default:
+			return nil, fmt.Errorf("JSON path %q: cannot descend into %q", path, elem)
+		}
+	}
+
+	return value, nil
+}
+
+// decodeText decodes the content into an UTF-8 string by using the charset of
+// the provided content type.
+func decodeText(contentType string, content []byte) (string, error) {
+	if contentType == "" {
+		return string(content), nil
+	}
+
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("parse content type %q: %w", contentType, err)
+	}
+
+	charset, ok := params["charset"]
+	if !ok || strings.EqualFold(charset, "utf-8") {
+		return string(content), nil
+	}
+
+	encoding, err := ianaindex.IANA.Encoding(charset)
+	if err != nil || encoding == nil {
+		return "", fmt.Errorf("unsupported charset %q", charset)
+	}
+
+	decoded, err := encoding.NewDecoder().Bytes(content)
+	if err != nil {
+		return "", fmt.Errorf("decode %s content: %w", charset, err)
+	}
+
+	return string(decoded), nil
+}
+
+// GetRequest sends a GET request to a URL and returns the request and response.
+func (a *Agent) GetRequest(url string) (response *http.Response, err error) {
+	return a.GetRequestWithContext(context.Background(), url)
+}
+
+// GetRequestWithContext sends a GET request bound to the provided context to
+// a URL and returns the request and response. Retries stop as soon as the
+// context is done.
+func (a *Agent) GetRequestWithContext(ctx context.Context, url string) (response *http.Response, err error) {
+	logrus.Debugf("Sending GET request to %s", a.options.redactURL(url))
+	a.logCurl(http.MethodGet, url, a.options.headers(), nil)
+
+	return a.retryRequest(ctx, func() (*http.Response, error) {
+		return a.sendGetRequest(ctx, url)
+	})
+}
+
+// Post returns the body of a POST request.
+func (a *Agent) Post(url string, postData []byte) (content []byte, err error) {
+	return a.PostWithContext(context.Background(), url, postData)
+}
+
+// PostWithContext returns the body of a POST request bound to the provided
+// context.
+func (a *Agent) PostWithContext(ctx context.Context, url string, postData []byte) (content []byte, err error) {
+	response, err := a.PostRequestWithContext(ctx, url, postData)
+	if err != nil {
+		return nil, fmt.Errorf("getting post request: %w", err)
+	}
+	defer response.Body.Close()
+
+	return a.readResponseToByteArray(response, a.options.ProgressCallback)
+}
+
+// PostRequest sends the postData in a POST request to a URL and returns the request object.
+func (a *Agent) PostRequest(url string, postData []byte) (response *http.Response, err error) {
+	return a.PostRequestWithContext(context.Background(), url, postData)
+}
+
+// PostRequestWithContext sends the postData in a POST request bound to the
+// provided context to a URL and returns the request object. Retries stop as
+// soon as the context is done.
+func (a *Agent) PostRequestWithContext(
+	ctx context.Context, url string, postData []byte,
+) (response *http.Response, err error) {
+	logrus.Debugf("Sending POST request to %s", a.options.redactURL(url))
+	a.logCurl(http.MethodPost, url, a.postHeaders(a.options.PostContentType), postData)
+
+	return a.retryRequest(ctx, func() (*http.Response, error) {
+		return a.sendPostRequest(ctx, url, postData, a.options.PostContentType)
+	})
+}
+
+// PostReader returns the body of a POST request, which streams its data from
+// the provided reader instead of loading it into memory. The contentLength
+// is the size of the data in bytes or -1 if unknown.
+//
+// A reader can only be consumed once, which means that retries are disabled
+// unless the reader also implements io.Seeker. In that case the agent seeks
+// back to the start of the reader before every attempt.
+func (a *Agent) PostReader(url string, body io.Reader, contentLength int64) (content []byte, err error) {
+	return a.PostReaderWithContext(context.Background(), url, body, contentLength)
+}
+
+// PostReaderWithContext returns the body of a POST request bound to the
+// provided context, which streams its data from the provided reader.
+func (a *Agent) PostReaderWithContext(
+	ctx context.Context, url string, body io.Reader, contentLength int64,
+) (content []byte, err error) {
+	response, err := a.PostReaderRequestWithContext(ctx, url, body, contentLength)
+	if err != nil {
+		return nil, fmt.Errorf("getting post request: %w", err)
+	}
+	defer response.Body.Close()
+
+	return a.readResponseToByteArray(response, a.options.ProgressCallback)
+}
+
+// PostReaderRequest sends a POST request with the data of the provided reader
+// to a URL and returns the response. Retries follow the same rules as for
+// PostReader.
+func (a *Agent) PostReaderRequest(url string, body io.Reader, contentLength int64) (response *http.Response, err error) {
+	return a.PostReaderRequestWithContext(context.Background(), url, body, contentLength)
+}
+
+// PostReaderRequestWithContext sends a POST request bound to the provided
+// context with the data of the provided reader to a URL and returns the
+// response. Retries stop as soon as the context is done.
+func (a *Agent) PostReaderRequestWithContext(
+	ctx context.Context, url string, body io.Reader, contentLength int64,
+) (response *http.Response, err error) {
+	logrus.Debugf("Sending POST request to %s", a.options.redactURL(url))
+	a.logCurl(http.MethodPost, url, a.postHeaders(a.options.PostContentType), nil)
+
+	seeker, ok := body.(io.Seeker)
+	if !ok {
+		// Non seekable readers can only be consumed once
+		response, err = a.sendPostReaderRequest(ctx, url, body, contentLength, a.options.PostContentType)
+
+		return response, a.options.redactError(err)
+	}
+
+	return a.retryRequest(ctx, func() (*http.Response, error) {
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return nil, retry.Unrecoverable(fmt.Errorf("rewinding request body: %w", err))
+		}
+
+		return a.sendPostReaderRequest(ctx, url, body, contentLength, a.options.PostContentType)
+	})
+}
+
+// Put returns the body of a PUT request.
+func (a *Agent) Put(url string, putData []byte) (content []byte, err error) {
+	return a.PutWithContext(context.Background(), url, putData)
+}
+
+// PutWithContext returns the body of a PUT request bound to the provided
+// context.
+func (a *Agent) PutWithContext(ctx context.Context, url string, putData []byte) (content []byte, err error) {
+	response, err := a.PutRequestWithContext(ctx, url, putData)
+	if err != nil {
+		return nil, fmt.Errorf("getting put request: %w", err)
+	}
+	defer response.Body.Close()
+
+	return a.readResponseToByteArray(response, a.options.ProgressCallback)
+}
+
+// PutRequest sends the putData in a PUT request to a URL and returns the request object.
+func (a *Agent) PutRequest(url string, putData []byte) (response *http.Response, err error) {
+	return a.PutRequestWithContext(context.Background(), url, putData)
+}
+
+// PutRequestWithContext sends the putData in a PUT request bound to the
+// provided context to a URL and returns the request object. Retries stop as
+// soon as the context is done.
+func (a *Agent) PutRequestWithContext(
+	ctx context.Context, url string, putData []byte,
+) (response *http.Response, err error) {
+	logrus.Debugf("Sending PUT request to %s", a.options.redactURL(url))
+	a.logCurl(http.MethodPut, url, a.postHeaders(a.options.PostContentType), putData)
+
+	return a.retryRequest(ctx, func() (*http.Response, error) {
+		return a.sendPutRequest(ctx, url, putData, a.options.PostContentType)
+	})
+}
+
+// Delete returns the body of a DELETE request.
+func (a *Agent) Delete(url string) (content []byte, err error) {
+	return a.DeleteWithContext(context.Background(), url)
+}
+
+// DeleteWithContext returns the body of a DELETE request bound to the
+// provided context.
+func (a *Agent) DeleteWithContext(ctx context.Context, url string) (content []byte, err error) {
+	response, err := a.DeleteRequestWithContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("getting delete request: %w", err)
+	}
+	defer response.Body.Close()
+
+	return a.readResponseToByteArray(response, a.options.ProgressCallback)
+}
+
+// DeleteRequest sends a DELETE request to a URL and returns the request and
+// response.
+func (a *Agent) DeleteRequest(url string) (response *http.Response, err error) {
+	return a.DeleteRequestWithContext(context.Background(), url)
+}
+
+// DeleteRequestWithContext sends a DELETE request bound to the provided
+// context to a URL and returns the request and response. Retries stop as soon
+// as the context is done.
+func (a *Agent) DeleteRequestWithContext(ctx context.Context, url string) (response *http.Response, err error) {
+	logrus.Debugf("Sending DELETE request to %s", a.options.redactURL(url))
+	a.logCurl(http.MethodDelete, url, a.options.headers(), nil)
+
+	return a.retryRequest(ctx, func() (*http.Response, error) {
+		return a.sendDeleteRequest(ctx, url)
+	})
+}
+
+func (a *Agent) retryRequest(
+	ctx context.Context, do func() (*http.Response, error),
+) (response *http.Response, err error) {
+	err = retry.Do(func() error {
+		//nolint:bodyclose // The API consumer should close the body
+		response, err = do()
+		if retryErr := a.options.shouldRetry(response, err); retryErr != nil {
+			return withRetryAfter(response, retryErr)
+		}
+
+		return nil
+	},
+		retry.Context(ctx),
+		retry.Attempts(a.options.attempts()),
+		retry.Delay(a.options.WaitTime),
+		retry.MaxDelay(a.options.MaxWaitTime),
+		retry.DelayType(a.retryDelay),
+		retry.OnRetry(func(attempt uint, err error) {
+			logrus.Errorf(
+				"Unable to do request (attempt %d/%d): %v",
+				attempt+1, a.options.attempts(), a.options.redactError(err),
+			)
+		}),
+	)
+
+	return response, a.options.redactError(err)
+}
+
+// attempts returns the total number of attempts for a request, which is at
+// least one. Note that zero attempts would make retry.Do retry forever.
+func (ao *agentOptions) attempts() uint {
+	return max(ao.Retries, 1)
+}
+
+// shouldRetry returns an error if the request should be retried. Next to URL
+// errors, this depends on the response status code, where either the ones set
+// via WithRetryableStatusCodes or the defaults are considered.
+func (ao *agentOptions) shouldRetry(resp *http.Response, err error) error {
+	urlErr := &url.Error{}
+	if err != nil && errors.As(err, &urlErr) {
+		return err
+	}
+
+	if resp == nil {
+		return err
+	}
+
+	if ao.RetryableStatusCodes != nil {
+		return ao.retryStatusCode(resp)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("retry %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	if resp.StatusCode == 0 || (resp.StatusCode >= 500 &&
+		resp.StatusCode != http.StatusNotImplemented) {
+		return fmt.Errorf("retry unexpected HTTP status %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	return nil
+}
+
+// retryStatusCode returns an error if the status code of the response is one
+// of those set via WithRetryableStatusCodes.
+func (ao *agentOptions) retryStatusCode(resp *http.Response) error {
+	if _, ok := ao.RetryableStatusCodes[resp.StatusCode]; ok {
+		return fmt.Errorf("retry HTTP status %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	return nil
+}
+
+// Head returns the body of a HEAD request.
+func (a *Agent) Head(url string) (content []byte, err error) {
+	return a.HeadWithContext(context.Background(), url)
+}
+
+// HeadWithContext returns the body of a HEAD request bound to the provided
+// context.
+func (a *Agent) HeadWithContext(ctx context.Context, url string) (content []byte, err error) {
+	response, err := a.HeadRequestWithContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("getting head request: %w", err)
+	}
+	defer response.Body.Close()
+
+	return a.readResponseToByteArray(response, a.options.ProgressCallback)
+}
+
+// HeadInfo is the parsed metadata of a HEAD request. Absent headers result
+// in zero values.
+type HeadInfo struct {
+	StatusCode    int       // HTTP status code of the response
+	ContentLength int64     // Size of the resource in bytes
+	ContentType   string    // Value of the Content-Type header
+	ETag          string    // Value of the ETag header
+	LastModified  time.Time // Parsed value of the Last-Modified header
+	AcceptsRanges bool      // True if the server supports byte range requests
+}
+
+// HeadInfo sends a HEAD request to a URL and returns the parsed metadata of
+// the response. If FailOnHTTPError is set, a status code outside of the 200s
+// results in an error, while the metadata is still returned.
+func (a *Agent) HeadInfo(url string) (HeadInfo, error) {
+	response, err := a.HeadRequest(url)
+	if err != nil {
+		return HeadInfo{}, fmt.Errorf("getting head request: %w", err)
+	}
+	defer response.Body.Close()
+
+	info := HeadInfo{
+		StatusCode:    response.StatusCode,
+		ContentLength: max(response.ContentLength, 0),
+		ContentType:   response.Header.Get("Content-Type"),
+		ETag:          response.Header.Get("ETag"),
+		AcceptsRanges: strings.EqualFold(strings.TrimSpace(response.Header.Get("Accept-Ranges")), "bytes"),
+	}
+
+	if lastModified, err := http.ParseTime(response.Header.Get("Last-Modified")); err == nil {
+		info.LastModified = lastModified
+	}
+
+	if a.options.FailOnHTTPError && (response.StatusCode < 200 || response.StatusCode >= 300) {
+		return info, newHTTPError(response)
+	}
+
+	return info, nil
+}
+
+// HeadRequest sends a HEAD request to a URL and returns the request and response.
+func (a *Agent) HeadRequest(url string) (response *http.Response, err error) {
+	return a.HeadRequestWithContext(context.Background(), url)
+}
+
+// HeadRequestWithContext sends a HEAD request bound to the provided context
+// to a URL and returns the request and response. Retries stop as soon as the
+// context is done.
+func (a *Agent) HeadRequestWithContext(ctx context.Context, url string) (response *http.Response, err error) {
+	logrus.Debugf("Sending HEAD request to %s", a.options.redactURL(url))
+	a.logCurl(http.MethodHead, url, a.options.headers(), nil)
+
+	var try uint
+
+	for {
+		response, err = a.sendHeadRequest(ctx, url)
+		try++
+
+		if err == nil && a.options.RetryableStatusCodes != nil {
+			err = a.options.retryStatusCode(response)
+		}
+
+		if err == nil || try >= a.options.attempts() {
+			return response, err
+		}
+		// Do exponential backoff...
+		waitTime := math.Pow(2, float64(try))
+		//  ... but wait no more than 1 min
+		if waitTime > 60 {
+			waitTime = a.options.MaxWaitTime.Seconds()
+		}
+
+		wait := time.Duration(waitTime) * time.Second
+		if a.options.Jitter {
+			wait = a.jitter(wait)
+		}
+
+		logrus.Errorf(
+			"Error getting URL (will retry %d more times in %.0f secs): %s",
+			a.options.attempts()-try, wait.Seconds(), err.Error(),
+		)
+
+		select {
+		case <-ctx.Done():
+			return response, fmt.Errorf("%w: %w", err, ctx.Err())
+		case <-time.After(wait):
+		}
+	}
+}
+
+// sendGetRequest sends a GET request by using the context-aware
+// implementation if available.
+func (a *Agent) sendGetRequest(ctx context.Context, url string) (*http.Response, error) {
+	return a.sendGetRequestWithClient(ctx, a.Client(), url)
+}
+
+// sendGetRequestWithClient behaves like sendGetRequest but uses the provided
+// client.
+func (a *Agent) sendGetRequestWithClient(
+	ctx context.Context, client *http.Client, url string,
+) (*http.Response, error) {
+	if err := a.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+
+	if impl, ok := a.AgentImplementation.(AgentContextImplementation); ok {
+		return impl.SendGetRequestWithContext(ctx, client, url)
+	}
+
+	return a.AgentImplementation.SendGetRequest(client, url)
+}
+
+// clientWithTimeout returns the client of the agent with the provided timeout
+// or the unmodified client if the timeout is not positive.
+func (a *Agent) clientWithTimeout(timeout time.Duration) *http.Client {
+	client := a.Client()
+	if timeout <= 0 {
+		return client
+	}
+
+	clientCopy := *client
+	clientCopy.Timeout = timeout
+
+	return &clientCopy
+}
+
+// sendPostRequest sends a POST request with the provided content type by
+// using the context-aware implementation if available.
+func (a *Agent) sendPostRequest(
+	ctx context.Context, url string, postData []byte, contentType string,
+) (*http.Response, error) {
+	if err := a.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+
+	if impl, ok := a.AgentImplementation.(AgentStreamImplementation); ok &&
+		a.options.StreamThreshold > 0 && int64(len(postData)) > a.options.StreamThreshold {
+		return a.streamPostRequest(ctx, impl, url, postData, contentType)
+	}
+
+	if impl, ok := a.AgentImplementation.(AgentContextImplementation); ok {
+		return impl.SendPostRequestWithContext(ctx, a.Client(), url, postData, contentType)
+	}
+
+	return a.AgentImplementation.SendPostRequest(a.Client(), url, postData, contentType)
+}
+
+// streamPostRequest writes the postData to a temporary file and streams the
+// POST request body from there.
+func (a *Agent) streamPostRequest(
+	ctx context.Context, impl AgentStreamImplementation, url string, postData []byte, contentType string,
+) (*http.Response, error) {
+	f, err := os.CreateTemp("", "http-post-")
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary file: %w", err)
+	}
+
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+
+	if _, err := f.Write(postData); err != nil {
+		return nil, fmt.Errorf("writing POST data to temporary file: %w", err)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("rewinding temporary file: %w", err)
+	}
+
+	return impl.SendPostRequestStream(ctx, a.Client(), url, f, int64(len(postData)), contentType)
+}
+
+// sendPostReaderRequest sends a POST request with the data of the reader and
+// the provided content type by using the context-aware implementation if
+// available.
+func (a *Agent) sendPostReaderRequest(
+	ctx context.Context, url string, body io.Reader, contentLength int64, contentType string,
+) (*http.Response, error) {
+	if err := a.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+
+	if impl, ok := a.AgentImplementation.(AgentContextImplementation); ok {
+		return impl.SendPostReaderRequestWithContext(ctx, a.Client(), url, body, contentLength, contentType)
+	}
+
+	return a.AgentImplementation.SendPostReaderRequest(a.Client(), url, body, contentLength, contentType)
+}
+
+// sendHeadRequest sends a HEAD request by using the context-aware
+// implementation if available.
+func (a *Agent) sendHeadRequest(ctx context.Context, url string) (*http.Response, error) {
+	if err := a.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+
+	if impl, ok := a.AgentImplementation.(AgentContextImplementation); ok {
+		return impl.SendHeadRequestWithContext(ctx, a.Client(), url)
+	}
+
+	return a.AgentImplementation.SendHeadRequest(a.Client(), url)
+}
+
+// sendPutRequest sends a PUT request with the provided content type by using
+// the context-aware implementation if available.
+func (a *Agent) sendPutRequest(
+	ctx context.Context, url string, putData []byte, contentType string,
+) (*http.Response, error) {
+	if err := a.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+
+	if impl, ok := a.AgentImplementation.(AgentContextImplementation); ok {
+		return impl.SendPutRequestWithContext(ctx, a.Client(), url, putData, contentType)
+	}
+
+	return a.AgentImplementation.SendPutRequest(a.Client(), url, putData, contentType)
+}
+
+// sendDeleteRequest sends a DELETE request by using the context-aware
+// implementation if available.
+func (a *Agent) sendDeleteRequest(ctx context.Context, url string) (*http.Response, error) {
+	if err := a.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+
+	if impl, ok := a.AgentImplementation.(AgentContextImplementation); ok {
+		return impl.SendDeleteRequestWithContext(ctx, a.Client(), url)
+	}
+
+	return a.AgentImplementation.SendDeleteRequest(a.Client(), url)
+}
+
+// SendPostRequest sends the actual HTTP post to the server.
+func (impl *defaultAgentImplementation) SendPostRequest(
+	client *http.Client, url string, postData []byte, contentType string,
+) (response *http.Response, err error) {
+	return impl.SendPostRequestWithContext(context.Background(), client, url, postData, contentType)
+}
+
+// SendPostRequestWithContext sends the actual HTTP post bound to the provided
+// context to the server.
+func (impl *defaultAgentImplementation) SendPostRequestWithContext(
+	ctx context.Context, client *http.Client, url string, postData []byte, contentType string,
+) (response *http.Response, err error) {
+	if contentType == "" {
+		contentType = defaultPostContentType
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(postData))
+	if err != nil {
+		return nil, fmt.Errorf("creating POST request for %s: %w", url, err)
+	}
+
+	request.Header.Set("Content-Type", contentType)
+
+	response, err = client.Do(request)
+	if err != nil {
+		return response, fmt.Errorf("posting data to %s: %w", url, err)
+	}
+
+	return response, nil
+}
+
+// SendPostReaderRequest sends the actual HTTP post with the data of the
+// reader to the server.
+func (impl *defaultAgentImplementation) SendPostReaderRequest(
+	client *http.Client, url string, body io.Reader, contentLength int64, contentType string,
+) (response *http.Response, err error) {
+	return impl.SendPostReaderRequestWithContext(context.Background(), client, url, body, contentLength, contentType)
+}
+
+// SendPostReaderRequestWithContext sends the actual HTTP post with the data
+// of the reader bound to the provided context to the server. A negative
+// contentLength means that the size of the data is unknown.
+func (impl *defaultAgentImplementation) SendPostReaderRequestWithContext(
+	ctx context.Context, client *http.Client, url string, body io.Reader, contentLength int64, contentType string,
+) (response *http.Response, err error) {
+	if contentType == "" {
+		contentType = defaultPostContentType
+	}
+
+	// Prevent the transport from closing the body, which is owned by the caller
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, io.NopCloser(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating POST request for %s: %w", url, err)
+	}
+
+	if contentLength < 0 {
+		contentLength = -1
+	}
+
+	request.ContentLength = contentLength
+	request.Header.Set("Content-Type", contentType)
+
+	response, err = client.Do(request)
+	if err != nil {
+		return response, fmt.Errorf("posting data to %s: %w", url, err)
+	}
+
+	return response, nil
+}
+
+// SendPostRequestStream sends the actual HTTP post bound to the provided
+// context to the server while streaming the body from the reader.
+func (impl *defaultAgentImplementation) SendPostRequestStream(
+	ctx context.Context, client *http.Client, url string, body io.ReadSeeker, size int64, contentType string,
+) (response *http.Response, err error) {
+	if contentType == "" {
+		contentType = defaultPostContentType
+	}
+
+	// Prevent the transport from closing the body, which is owned by the caller
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, io.NopCloser(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating POST request for %s: %w", url, err)
+	}
+
+	request.ContentLength = size
+	request.GetBody = func() (io.ReadCloser, error) {
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("rewinding request body: %w", err)
+		}
+
+		return io.NopCloser(body), nil
+	}
+	request.Header.Set("Content-Type", contentType)
+
+	response, err = client.Do(request)
+	if err != nil {
+		return response, fmt.Errorf("posting data to %s: %w", url, err)
+	}
+
+	return response, nil
+}
+
+// SendGetRequest performs the actual request.
+func (impl *defaultAgentImplementation) SendGetRequest(client *http.Client, url string) (
+	response *http.Response, err error,
+) {
+	return impl.SendGetRequestWithContext(context.Background(), client, url)
+}
+
+// SendGetRequestWithContext performs the actual request bound to the provided
+// context.
+func (impl *defaultAgentImplementation) SendGetRequestWithContext(
+	ctx context.Context, client *http.Client, url string,
+) (response *http.Response, err error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("creating GET request for %s: %w", url, err)
+	}
+
+	response, err = client.Do(request)
+	if err != nil {
+		return response, fmt.Errorf("getting %s: %w", url, err)
+	}
+
+	return response, nil
+}
+
+// SendHeadRequest performs the actual request.
+func (impl *defaultAgentImplementation) SendHeadRequest(client *http.Client, url string) (
+	response *http.Response, err error,
+) {
+	return impl.SendHeadRequestWithContext(context.Background(), client, url)
+}
+
+// SendHeadRequestWithContext performs the actual request bound to the
+// provided context.
+func (impl *defaultAgentImplementation) SendHeadRequestWithContext(
+	ctx context.Context, client *http.Client, url string,
+) (response *http.Response, err error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, url, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("creating HEAD request for %s: %w", url, err)
+	}
+
+	response, err = client.Do(request)
+	if err != nil {
+		return response, fmt.Errorf("sending head request %s: %w", url, err)
+	}
+
+	return response, nil
+}
+
+// SendPutRequest sends the actual HTTP put to the server.
+func (impl *defaultAgentImplementation) SendPutRequest(
+	client *http.Client, url string, putData []byte, contentType string,
+) (response *http.Response, err error) {
+	return impl.SendPutRequestWithContext(context.Background(), client, url, putData, contentType)
+}
+
+// SendPutRequestWithContext sends the actual HTTP put bound to the provided
+// context to the server.
+func (impl *defaultAgentImplementation) SendPutRequestWithContext(
+	ctx context.Context, client *http.Client, url string, putData []byte, contentType string,
+) (response *http.Response, err error) {
+	if contentType == "" {
+		contentType = defaultPostContentType
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(putData))
+	if err != nil {
+		return nil, fmt.Errorf("creating PUT request for %s: %w", url, err)
+	}
+
+	request.Header.Set("Content-Type", contentType)
+
+	response, err = client.Do(request)
+	if err != nil {
+		return response, fmt.Errorf("putting data to %s: %w", url, err)
+	}
+
+	return response, nil
+}
+
+// SendDeleteRequest performs the actual request.
+func (impl *defaultAgentImplementation) SendDeleteRequest(client *http.Client, url string) (
+	response *http.Response, err error,
+) {
+	return impl.SendDeleteRequestWithContext(context.Background(), client, url)
+}
+
+// SendDeleteRequestWithContext performs the actual request bound to the
+// provided context.
+func (impl *defaultAgentImplementation) SendDeleteRequestWithContext(
+	ctx context.Context, client *http.Client, url string,
+) (response *http.Response, err error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("creating DELETE request for %s: %w", url, err)
+	}
+
+	response, err = client.Do(request)
+	if err != nil {
+		return response, fmt.Errorf("deleting %s: %w", url, err)
+	}
+
+	return response, nil
+}
+
+// sensitiveHeaders are the headers which get fully redacted when logging
+// requests as curl commands.
+var sensitiveHeaders = map[string]struct{}{
+	"Authorization":       {},
+	"Proxy-Authorization": {},
+	"Cookie":              {},
+}
+
+// postHeaders returns the headers sent along with POST and PUT requests of the
+// provided content type.
+func (a *Agent) postHeaders(contentType string) http.Header {
+	if contentType == "" {
+		contentType = defaultPostContentType
+	}
+
+	headers := a.options.headers()
+	headers.Set("Content-Type", contentType)
+
+	return headers
+}
+
+// logCurl logs the request as equivalent curl command if enabled.
+func (a *Agent) logCurl(method, url string, headers http.Header, body []byte) {
+	if !a.options.LogAsCurl || !logrus.IsLevelEnabled(logrus.DebugLevel) {
+		return
+	}
+
+	logrus.Debugf(
+		"Request as curl command: %s",
+		a.options.redactCredentials(curlCommand(method, a.options.redactURL(url), headers, body)),
+	)
+}
+
+// curlCommand returns the curl command line for the provided request data.
+// Sensitive data will be redacted.
+func curlCommand(method, url string, headers http.Header, body []byte) string {
+	args := []string{"curl", "-X", method}
+
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, value := range headers[key] {
+			if _, ok := sensitiveHeaders[http.CanonicalHeaderKey(key)]; ok {
+				value = redact.Sanitized
+			}
+
+			args = append(args, "-H", shellQuote(key+": "+redactData(value)))
+		}
+	}
+
+	if body != nil {
+		args = append(args, "-d", shellQuote(redactData(string(body))))
+	}
+
+	return strings.Join(append(args, shellQuote(redactData(url))), " ")
+}
+
+// redactData removes sensitive data from the provided string.
+func redactData(s string) string {
+	return string(redact.Data([]byte(s)))
+}
+
+// shellQuote quotes the string to be safely used as a single shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// readResponseToByteArray returns the contents of an http response as a byte array.
+func (a *Agent) readResponseToByteArray(response *http.Response, progress func(written, total int64)) ([]byte, error) {
+	var b bytes.Buffer
+	if err := a.readResponse(response, &b, progress); err != nil {
+		return nil, fmt.Errorf("reading array buffer: %w", err)
+	}
+
+	return b.Bytes(), nil
+}
+
+// readResponse reads and interprets the response to an HTTP request to an io.Writer.
+// If the response status is < 200 or >= 300 and FailOnHTTPError is set, the function
+// will return an error. The optional progress callback is invoked for every
+// chunk written.
+//
+// This function will close the response body reader.
+func (a *Agent) readResponse(
+	response *http.Response, w io.Writer, progress func(written, total int64),
+) (err error) {
+	// Read the response body
+	defer response.Body.Close()
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		if err := a.checkContentType(response); err != nil {
+			return err
+		}
+	}
+
+	body := io.Reader(response.Body)
+	total := response.ContentLength
+
+	if a.options.AutoDecompress {
+		decompressed, err := decompressBody(response)
+		if err != nil {
+			return fmt.Errorf("decompressing response: %w", err)
+		}
+
+		if decompressed != nil {
+			defer decompressed.Close()
+
+			body = decompressed
+			total = -1
+		}
+	}
+
+	if progress != nil {
+		w = &progressWriter{w: w, total: total, callback: progress}
+	}
+
+	if err := a.copyBody(w, body); err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+
+	// Check the https response code
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		if a.options.FailOnHTTPError {
+			return newHTTPError(response)
+		}
+
+		logrus.Warnf("Got HTTP error but FailOnHTTPError not set: %s", response.Status)
+	}
+
+	return err
+}
+
+// decompressBody returns a reader which decompresses the response body
+// according to its Content-Encoding header, or nil if the body is not encoded
+// or the encoding is not supported.
+func decompressBody(response *http.Response) (io.ReadCloser, error) {
+	encoding := strings.ToLower(strings.TrimSpace(response.Header.Get("Content-Encoding")))
+
+	switch encoding {
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(response.Body)
+		if errors.Is(err, io.EOF) {
+			// Empty body, nothing to decompress
+			return nil, nil
+		}
+
+		return reader, err
+	case "deflate":
+		return flate.NewReader(response.Body), nil
+	default:
+		return nil, nil
+	}
+}
+
+// progressWriter counts the bytes written to the underlying writer and
+// reports them to the callback.
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	total    int64
+	callback func(written, total int64)
+}
+
+// Write implements the io.Writer interface.
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	p.callback(p.written, p.total)
+
+	return n, err
+}
+
+// groupProgress returns the progress callback for the request of a group at
+// the provided index or nil if none is set.
+func (a *Agent) groupProgress(index int) func(written, total int64) {
+	if a.options.GroupProgressCallback == nil {
+		return nil
+	}
+
+	return func(written, total int64) {
+		a.options.GroupProgressCallback(index, written, total)
+	}
+}
+
+// copyBody copies the response body to the writer while enforcing the
+// maximum body size.
+func (a *Agent) copyBody(w io.Writer, body io.Reader) error {
+	limit := a.options.MaxBodySize
+	if limit <= 0 {
+		_, err := io.Copy(w, body)
+
+		return err
+	}
+
+	if _, err := io.Copy(w, io.LimitReader(body, limit)); err != nil {
+		return err
+	}
+
+	// Probe for a single byte exceeding the limit.
+	n, err := io.CopyN(io.Discard, body, 1)
+	if n > 0 {
+		return fmt.Errorf("%w: exceeds %d bytes", ErrBodyTooLarge, limit)
+	}
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return nil
+}
+
+// checkContentType verifies that the response has the expected content type.
+// The returned error contains a snippet of the body to ease debugging.
+func (a *Agent) checkContentType(response *http.Response) error {
+	expected := a.options.ExpectedContentType
+	if expected == "" {
+		return nil
+	}
+
+	contentType := response.Header.Get("Content-Type")
+	mediaType, _, _ := strings.Cut(contentType, ";")
+
+	if strings.HasPrefix(
+		strings.ToLower(strings.TrimSpace(mediaType)), strings.ToLower(expected),
+	) {
+		return nil
+	}
+
+	snippet, err := io.ReadAll(io.LimitReader(response.Body, contentTypeSnippetSize))
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+
+	return fmt.Errorf(
+		"%w: expected %q but got %q, body starts with: %q",
+		ErrUnexpectedContentType, expected, contentType, snippet,
+	)
+}
+
+// GetToWriter sends a get request and writes the response to an io.Writer.
+func (a *Agent) GetToWriter(w io.Writer, url string) error {
+	return a.GetToWriterWithContext(context.Background(), w, url)
+}
+
+// GetToWriterWithContext sends a get request bound to the provided context
+// and writes the response to an io.Writer.
+func (a *Agent) GetToWriterWithContext(ctx context.Context, w io.Writer, url string) error {
+	return a.getToWriter(ctx, w, url, a.options.ProgressCallback)
 }
 
-// GetToWriter sends a get request and writes the response to an io.Writer.
-func (a *Agent) GetToWriter(w io.Writer, url string) error {
-	resp, err := a.AgentImplementation.SendGetRequest(a.Client(), url)
+// getToWriter sends a get request and writes the response to an io.Writer
+// while reporting the progress to the optional callback.
+func (a *Agent) getToWriter(
+	ctx context.Context, w io.Writer, url string, progress func(written, total int64),
+) error {
+	a.logCurl(http.MethodGet, url, a.options.headers(), nil)
+
+	resp, err := a.sendGetRequest(ctx, url)
 	if err != nil {
 		return fmt.Errorf("sending GET request: %w", err)
 	}
 
-	return a.readResponse(resp, w)
+	return a.readResponse(resp, w, progress)
 }
 
 // PostToWriter sends a request to a url and writes the response to an io.Writer.
 func (a *Agent) PostToWriter(w io.Writer, url string, postData []byte) error {
-	resp, err := a.AgentImplementation.SendPostRequest(a.Client(), url, postData, a.options.PostContentType)
+	return a.PostToWriterWithContext(context.Background(), w, url, postData)
+}
+
+// PostToWriterWithContext sends a request bound to the provided context to a
+// url and writes the response to an io.Writer.
+func (a *Agent) PostToWriterWithContext(ctx context.Context, w io.Writer, url string, postData []byte) error {
+	a.logCurl(http.MethodPost, url, a.postHeaders(a.options.PostContentType), postData)
+
+	resp, err := a.sendPostRequest(ctx, url, postData, a.options.PostContentType)
 	if err != nil {
 		return fmt.Errorf("sending POST request: %w", err)
 	}
 
-	return a.readResponse(resp, w)
+	return a.readResponse(resp, w, a.options.ProgressCallback)
+}
+
+// PutToWriter sends a PUT request to a url and writes the response to an io.Writer.
+func (a *Agent) PutToWriter(w io.Writer, url string, putData []byte) error {
+	return a.PutToWriterWithContext(context.Background(), w, url, putData)
+}
+
+// PutToWriterWithContext sends a PUT request bound to the provided context to
+// a url and writes the response to an io.Writer.
+func (a *Agent) PutToWriterWithContext(ctx context.Context, w io.Writer, url string, putData []byte) error {
+	a.logCurl(http.MethodPut, url, a.postHeaders(a.options.PostContentType), putData)
+
+	resp, err := a.sendPutRequest(ctx, url, putData, a.options.PostContentType)
+	if err != nil {
+		return fmt.Errorf("sending PUT request: %w", err)
+	}
+
+	return a.readResponse(resp, w, a.options.ProgressCallback)
 }
 
 // GetRequestGroup behaves like agent.SendGetRequest() but takes a group of URLs
 // and performs the requests in parallel. The number of simultaneous requests is
 // controlled by options.MaxParallel.
 func (a *Agent) GetRequestGroup(urls []string) ([]*http.Response, []error) {
+	return a.GetRequestGroupWithContext(context.Background(), urls)
+}
+
+// GetRequestGroupWithContext behaves like GetRequestGroup() but binds all
+// requests to the provided context. No further requests are dispatched once
+// the context is done, which means that they will fail with the context
+// error.
+func (a *Agent) GetRequestGroupWithContext(ctx context.Context, urls []string) ([]*http.Response, []error) {
+	return a.requestGroup(ctx, http.MethodGet, urls, withoutIndex(a.sendGetRequest))
+}
+
+// GetRequestGroupWithTimeouts behaves like GetRequestGroup() but uses the
+// timeout at the same index as the URL for each request, which overrides the
+// timeout of the agent. A zero timeout uses the one of the agent, as well as
+// an empty list of timeouts for all requests.
+//
+// The lists of URLs and timeouts are required to be of equal length if
+// timeouts are provided. If not, the function will exit early, failing all
+// requests.
+func (a *Agent) GetRequestGroupWithTimeouts(
+	urls []string, timeouts []time.Duration,
+) ([]*http.Response, []error) {
+	return a.GetRequestGroupWithTimeoutsWithContext(context.Background(), urls, timeouts)
+}
+
+// GetRequestGroupWithTimeoutsWithContext behaves like
+// GetRequestGroupWithTimeouts() but binds all requests to the provided
+// context.
+func (a *Agent) GetRequestGroupWithTimeoutsWithContext(
+	ctx context.Context, urls []string, timeouts []time.Duration,
+) ([]*http.Response, []error) {
+	if len(timeouts) == 0 {
+		return a.GetRequestGroupWithContext(ctx, urls)
+	}
+
+	if len(timeouts) != len(urls) {
+		errs := make([]error, len(urls))
+		for i := range urls {
+			errs[i] = errors.New("unable to perform requests, same number URLs and timeouts required")
+		}
+
+		return make([]*http.Response, len(urls)), errs
+	}
+
+	return a.requestGroup(ctx, http.MethodGet, urls,
+		func(ctx context.Context, i int, url string) (*http.Response, error) {
+			return a.sendGetRequestWithClient(ctx, a.clientWithTimeout(timeouts[i]), url)
+		},
+	)
+}
+
+// withoutIndex adapts a send function to the signature used by requestGroup
+// by ignoring the index of the request.
+func withoutIndex(
+	send func(context.Context, string) (*http.Response, error),
+) func(context.Context, int, string) (*http.Response, error) {
+	return func(ctx context.Context, _ int, url string) (*http.Response, error) {
+		return send(ctx, url)
+	}
+}
+
+// requestGroup sends requests with the provided method to the URLs in
+// parallel. The send function receives the index of the URL in the list. The
+// number of simultaneous requests is controlled by options.MaxParallel.
+func (a *Agent) requestGroup(
+	ctx context.Context, method string, urls []string,
+	send func(context.Context, int, string) (*http.Response, error),
+) ([]*http.Response, []error) {
 	//nolint:gosec // integer overflow highly unlikely
 	t := throttler.New(int(a.options.MaxParallel), len(urls))
 	ret := make([]*http.Response, len(urls))
@@ -375,13 +1974,26 @@ func (a *Agent) GetRequestGroup(urls []string) ([]*http.Response, []error) {
 	m := sync.Mutex{}
 
 	for i := range urls {
+		if err := ctx.Err(); err != nil {
+			m.Lock()
+			errs[i] = err
+			m.Unlock()
+
+			t.Done(err)
+			t.Throttle()
+
+			continue
+		}
+
 		go func(url string) {
+			a.logCurl(method, url, a.options.headers(), nil)
+
 			//nolint: bodyclose // We don't close here as we're returning the response
-			resp, err := a.AgentImplementation.SendGetRequest(a.Client(), url)
+			resp, err := send(ctx, i, url)
 
 			m.Lock()
 			ret[i] = resp
-			errs[i] = err
+			errs[i] = a.options.redactError(err)
 			m.Unlock()
 
 			t.Done(err)
@@ -392,6 +2004,73 @@ func (a *Agent) GetRequestGroup(urls []string) ([]*http.Response, []error) {
 	return ret, errs
 }
 
+// HeadRequestGroup behaves like agent.HeadRequest() but takes a group of URLs
+// and performs the requests in parallel. The number of simultaneous requests
+// is controlled by options.MaxParallel.
+func (a *Agent) HeadRequestGroup(urls []string) ([]*http.Response, []error) {
+	return a.HeadRequestGroupWithContext(context.Background(), urls)
+}
+
+// HeadRequestGroupWithContext behaves like HeadRequestGroup() but binds all
+// requests to the provided context. No further requests are dispatched once
+// the context is done, which means that they will fail with the context
+// error.
+func (a *Agent) HeadRequestGroupWithContext(ctx context.Context, urls []string) ([]*http.Response, []error) {
+	return a.requestGroup(ctx, http.MethodHead, urls, withoutIndex(a.sendHeadRequest))
+}
+
+// ContentLengthGroup sends HEAD requests to a group of URLs in parallel and
+// returns the Content-Length of each response, or -1 if it is unknown. This
+// is useful to determine the total size of a batch of downloads upfront. If
+// FailOnHTTPError is set, a status code outside of the 200s results in an
+// HTTPError for the corresponding URL.
+func (a *Agent) ContentLengthGroup(urls []string) ([]int64, []error) {
+	return a.ContentLengthGroupWithContext(context.Background(), urls)
+}
+
+// ContentLengthGroupWithContext behaves like ContentLengthGroup() but binds
+// all requests to the provided context.
+func (a *Agent) ContentLengthGroupWithContext(ctx context.Context, urls []string) ([]int64, []error) {
+	//nolint: bodyclose // Next line closes them
+	resps, errs := a.HeadRequestGroupWithContext(ctx, urls)
+	defer closeHTTPResponseGroup(resps)
+
+	lengths := make([]int64, len(urls))
+
+	for i, r := range resps {
+		lengths[i] = -1
+
+		if r == nil {
+			continue
+		}
+
+		if a.options.FailOnHTTPError && (r.StatusCode < 200 || r.StatusCode >= 300) {
+			errs[i] = newHTTPError(r)
+
+			continue
+		}
+
+		lengths[i] = r.ContentLength
+	}
+
+	return lengths, errs
+}
+
+// DeleteRequestGroup behaves like agent.DeleteRequest() but takes a group of
+// URLs and performs the requests in parallel. The number of simultaneous
+// requests is controlled by options.MaxParallel.
+func (a *Agent) DeleteRequestGroup(urls []string) ([]*http.Response, []error) {
+	return a.DeleteRequestGroupWithContext(context.Background(), urls)
+}
+
+// DeleteRequestGroupWithContext behaves like DeleteRequestGroup() but binds
+// all requests to the provided context. No further requests are dispatched
+// once the context is done, which means that they will fail with the context
+// error.
+func (a *Agent) DeleteRequestGroupWithContext(ctx context.Context, urls []string) ([]*http.Response, []error) {
+	return a.requestGroup(ctx, http.MethodDelete, urls, withoutIndex(a.sendDeleteRequest))
+}
+
 // PostRequestGroup behaves like agent.Post() but takes a group of URLs and performs the
 // requests in parallel. The number of simultaneous requests is controlled by
 // options.MaxParallel.
@@ -400,11 +2079,39 @@ func (a *Agent) GetRequestGroup(urls []string) ([]*http.Response, []error) {
 // If postData has less elements than the URL list, the function will exit early,
 // failing all requests.
 func (a *Agent) PostRequestGroup(urls []string, postData [][]byte) ([]*http.Response, []error) {
+	return a.PostRequestGroupWithContext(context.Background(), urls, postData)
+}
+
+// PostRequestGroupWithContext behaves like PostRequestGroup() but binds all
+// requests to the provided context. No further requests are dispatched once
+// the context is done, which means that they will fail with the context
+// error.
+func (a *Agent) PostRequestGroupWithContext(
+	ctx context.Context, urls []string, postData [][]byte,
+) ([]*http.Response, []error) {
+	return a.requestGroupWithData(ctx, http.MethodPost, urls, postData, nil, a.sendPostRequest)
+}
+
+// requestGroupWithData sends the data of each URL with the provided method in
+// parallel. The number of simultaneous requests is controlled by
+// options.MaxParallel. If contentTypes is nil, the content type set in the
+// options is used for all requests.
+func (a *Agent) requestGroupWithData(
+	ctx context.Context, method string, urls []string, data [][]byte, contentTypes []string,
+	send func(context.Context, string, []byte, string) (*http.Response, error),
+) ([]*http.Response, []error) {
 	ret := make([]*http.Response, len(urls))
 	errs := make([]error, len(urls))
-	// URLs and postData arrays must be equal in length. If not exit now.
-	if len(postData) != len(urls) {
-		err := errors.New("unable to perform requests, same number URLs and POST payloads required")
+
+	// URLs, data and content type arrays must be equal in length. If not exit now.
+	var err error
+	if len(data) != len(urls) {
+		err = fmt.Errorf("unable to perform requests, same number URLs and %s payloads required", method)
+	} else if contentTypes != nil && len(contentTypes) != len(urls) {
+		err = fmt.Errorf("unable to perform requests, same number URLs and %s content types required", method)
+	}
+
+	if err != nil {
 		for i := range urls {
 			errs[i] = err
 		}
@@ -417,24 +2124,65 @@ func (a *Agent) PostRequestGroup(urls []string, postData [][]byte) ([]*http.Resp
 	m := sync.Mutex{}
 
 	for i := range urls {
+		if err := ctx.Err(); err != nil {
+			m.Lock()
+			errs[i] = err
+			m.Unlock()
+
+			t.Done(err)
+			t.Throttle()
+
+			continue
+		}
+
+		contentType := a.options.PostContentType
+		if contentTypes != nil {
+			contentType = contentTypes[i]
+		}
+
 		go func(url string, pdata []byte) {
+			a.logCurl(method, url, a.postHeaders(contentType), pdata)
+
 			//nolint: bodyclose // We don't close here as we're returning the raw response
-			resp, err := a.AgentImplementation.SendPostRequest(
-				a.Client(), url, pdata, a.options.PostContentType,
-			)
+			resp, err := send(ctx, url, pdata, contentType)
 
 			m.Lock()
 			ret[i] = resp
-			errs[i] = err
+			errs[i] = a.options.redactError(err)
 			m.Unlock()
 			t.Done(err)
-		}(urls[i], postData[i])
+		}(urls[i], data[i])
 		t.Throttle()
 	}
 
 	return ret, errs
 }
 
+// PostRequestGroupWithContentTypes behaves like PostRequestGroup() but sends
+// each request with its own content type, which allows to post heterogeneous
+// data in a single group.
+//
+// The lists of URLs, postData byte arrays and content types are required to be
+// of equal length. If not, the function will exit early, failing all requests.
+func (a *Agent) PostRequestGroupWithContentTypes(
+	urls []string, postData [][]byte, contentTypes []string,
+) ([]*http.Response, []error) {
+	return a.PostRequestGroupWithContentTypesWithContext(context.Background(), urls, postData, contentTypes)
+}
+
+// PostRequestGroupWithContentTypesWithContext behaves like
+// PostRequestGroupWithContentTypes() but binds all requests to the provided
+// context.
+func (a *Agent) PostRequestGroupWithContentTypesWithContext(
+	ctx context.Context, urls []string, postData [][]byte, contentTypes []string,
+) ([]*http.Response, []error) {
+	if contentTypes == nil {
+		contentTypes = []string{}
+	}
+
+	return a.requestGroupWithData(ctx, http.MethodPost, urls, postData, contentTypes, a.sendPostRequest)
+}
+
 // PostGroup behaves just as Post() but takes a group of URLs and performs
 // the requests in parallel. The number of simultaneous requests is controlled by
 // options.MaxParallel.
@@ -443,15 +2191,27 @@ func (a *Agent) PostRequestGroup(urls []string, postData [][]byte) ([]*http.Resp
 // If postData has less elements than the url list, those urls without a corresponding
 // postData array will return an error.
 func (a *Agent) PostGroup(urls []string, postData [][]byte) ([][]byte, []error) {
-	//nolint: bodyclose // Next line closes them
-	resps, errs := a.PostRequestGroup(urls, postData)
+	return a.PostGroupWithContext(context.Background(), urls, postData)
+}
+
+// PostGroupWithContext behaves just as PostGroup() but binds all requests to
+// the provided context.
+func (a *Agent) PostGroupWithContext(ctx context.Context, urls []string, postData [][]byte) ([][]byte, []error) {
+	//nolint: bodyclose // readResponseGroup closes them
+	resps, errs := a.PostRequestGroupWithContext(ctx, urls, postData)
+
+	return a.readResponseGroup(resps, errs)
+}
+
+// readResponseGroup reads and closes the responses of a group request.
+func (a *Agent) readResponseGroup(resps []*http.Response, errs []error) ([][]byte, []error) {
 	defer closeHTTPResponseGroup(resps)
 
-	c := make([][]byte, len(urls))
+	c := make([][]byte, len(resps))
 
 	for i, r := range resps {
 		if r != nil {
-			d, err := a.readResponseToByteArray(r)
+			d, err := a.readResponseToByteArray(r, a.groupProgress(i))
 			if err != nil {
 				errs[i] = fmt.Errorf("reading group response #%d: %w", i, err)
 
@@ -490,8 +2250,23 @@ func closeHTTPResponseGroup(resps []*http.Response) {
 // is missing, in that case the request will return an error. The requests are
 // guaranteed to go into the writer in order.
 func (a *Agent) PostToWriterGroup(w []io.Writer, urls []string, postData [][]byte) []error {
-	//nolint: bodyclose // Next line closes them
-	resps, errs := a.PostRequestGroup(urls, postData)
+	return a.PostToWriterGroupWithContext(context.Background(), w, urls, postData)
+}
+
+// PostToWriterGroupWithContext behaves just as PostToWriterGroup() but binds
+// all requests to the provided context.
+func (a *Agent) PostToWriterGroupWithContext(
+	ctx context.Context, w []io.Writer, urls []string, postData [][]byte,
+) []error {
+	//nolint: bodyclose // writeResponseGroup closes them
+	resps, errs := a.PostRequestGroupWithContext(ctx, urls, postData)
+
+	return a.writeResponseGroup(w, resps, errs)
+}
+
+// writeResponseGroup writes the responses of a group request to the writers
+// and closes them.
+func (a *Agent) writeResponseGroup(w []io.Writer, resps []*http.Response, errs []error) []error {
 	defer closeHTTPResponseGroup(resps)
 
 	for i, r := range resps {
@@ -501,12 +2276,12 @@ func (a *Agent) PostToWriterGroup(w []io.Writer, urls []string, postData [][]byt
 
 		var err error
 		if len(w) == 1 {
-			err = a.readResponse(r, w[0])
+			err = a.readResponse(r, w[0], a.groupProgress(i))
 		} else {
 			if i >= len(w) {
 				err = fmt.Errorf("request %d has no writer defined", i)
 			} else {
-				err = a.readResponse(r, w[i])
+				err = a.readResponse(r, w[i], a.groupProgress(i))
 			}
 		}
 
@@ -520,30 +2295,85 @@ func (a *Agent) PostToWriterGroup(w []io.Writer, urls []string, postData [][]byt
 	return errs
 }
 
-// GetGroup behaves just as Get() but takes a group of URLs and performs
+// PutRequestGroup behaves like agent.Put() but takes a group of URLs and performs the
+// requests in parallel. The number of simultaneous requests is controlled by
+// options.MaxParallel.
+//
+// The list of URLs and putData byte arrays are required to be of equal length.
+// If putData has less elements than the URL list, the function will exit early,
+// failing all requests.
+func (a *Agent) PutRequestGroup(urls []string, putData [][]byte) ([]*http.Response, []error) {
+	return a.PutRequestGroupWithContext(context.Background(), urls, putData)
+}
+
+// PutRequestGroupWithContext behaves like PutRequestGroup() but binds all
+// requests to the provided context. No further requests are dispatched once
+// the context is done, which means that they will fail with the context
+// error.
+func (a *Agent) PutRequestGroupWithContext(
+	ctx context.Context, urls []string, putData [][]byte,
+) ([]*http.Response, []error) {
+	return a.requestGroupWithData(ctx, http.MethodPut, urls, putData, nil, a.sendPutRequest)
+}
+
+// PutGroup behaves just as Put() but takes a group of URLs and performs
 // the requests in parallel. The number of simultaneous requests is controlled by
 // options.MaxParallel.
-func (a *Agent) GetGroup(urls []string) ([][]byte, []error) {
-	//nolint: bodyclose // Next line closes them
-	resps, errs := a.GetRequestGroup(urls)
-	defer closeHTTPResponseGroup(resps)
+//
+// The list of URLs and putData byte arrays are expected to be of equal length.
+// If putData has less elements than the url list, those urls without a corresponding
+// putData array will return an error.
+func (a *Agent) PutGroup(urls []string, putData [][]byte) ([][]byte, []error) {
+	return a.PutGroupWithContext(context.Background(), urls, putData)
+}
 
-	c := make([][]byte, len(urls))
+// PutGroupWithContext behaves just as PutGroup() but binds all requests to
+// the provided context.
+func (a *Agent) PutGroupWithContext(ctx context.Context, urls []string, putData [][]byte) ([][]byte, []error) {
+	//nolint: bodyclose // readResponseGroup closes them
+	resps, errs := a.PutRequestGroupWithContext(ctx, urls, putData)
 
-	for i, r := range resps {
-		if r != nil {
-			d, err := a.readResponseToByteArray(r)
-			if err != nil {
-				errs[i] = fmt.Errorf("reading group response #%d: %w", i, err)
+	return a.readResponseGroup(resps, errs)
+}
 
-				continue
-			}
+// PutToWriterGroup behaves just as PutToWriter() but takes a group of URLs
+// and performs the requests in parallel. The number of simultaneous requests
+// is controlled by options.MaxParallel.
+//
+// The list of URLs and putData byte arrays are expected to be of equal length.
+// If putData has less elements than the url list, those urls without a corresponding
+// putData array will return an error.
+//
+// The writers are handled the same way as in PostToWriterGroup().
+func (a *Agent) PutToWriterGroup(w []io.Writer, urls []string, putData [][]byte) []error {
+	return a.PutToWriterGroupWithContext(context.Background(), w, urls, putData)
+}
 
-			c[i] = d
-		}
-	}
+// PutToWriterGroupWithContext behaves just as PutToWriterGroup() but binds
+// all requests to the provided context.
+func (a *Agent) PutToWriterGroupWithContext(
+	ctx context.Context, w []io.Writer, urls []string, putData [][]byte,
+) []error {
+	//nolint: bodyclose // writeResponseGroup closes them
+	resps, errs := a.PutRequestGroupWithContext(ctx, urls, putData)
 
-	return c, errs
+	return a.writeResponseGroup(w, resps, errs)
+}
+
+// GetGroup behaves just as Get() but takes a group of URLs and performs
+// the requests in parallel. The number of simultaneous requests is controlled by
+// options.MaxParallel.
+func (a *Agent) GetGroup(urls []string) ([][]byte, []error) {
+	return a.GetGroupWithContext(context.Background(), urls)
+}
+
+// GetGroupWithContext behaves just as GetGroup() but binds all requests to
+// the provided context.
+func (a *Agent) GetGroupWithContext(ctx context.Context, urls []string) ([][]byte, []error) {
+	//nolint: bodyclose // readResponseGroup closes them
+	resps, errs := a.GetRequestGroupWithContext(ctx, urls)
+
+	return a.readResponseGroup(resps, errs)
 }
 
 // GetToWriterGroup behaves just as GetToWriter() but takes a group of URLs
@@ -556,32 +2386,217 @@ func (a *Agent) GetGroup(urls []string) ([][]byte, []error) {
 // is missing in which case the request will return an error. The requests are
 // guaranteed to go into the writer in order.
 func (a *Agent) GetToWriterGroup(w []io.Writer, urls []string) []error {
-	//nolint: bodyclose
-	resps, errs := a.GetRequestGroup(urls)
-	defer closeHTTPResponseGroup(resps)
+	return a.GetToWriterGroupWithContext(context.Background(), w, urls)
+}
+
+// GetToWriterGroupWithContext behaves just as GetToWriterGroup() but binds
+// all requests to the provided context.
+func (a *Agent) GetToWriterGroupWithContext(ctx context.Context, w []io.Writer, urls []string) []error {
+	//nolint: bodyclose // writeResponseGroup closes them
+	resps, errs := a.GetRequestGroupWithContext(ctx, urls)
+
+	return a.writeResponseGroup(w, resps, errs)
+}
+
+// DownloadAll downloads all URLs in parallel into destDir, where the file
+// name is taken from the last element of the URL path. The number of
+// simultaneous requests is controlled by options.MaxParallel.
+//
+// It returns a map of file names to their hex-encoded SHA256 digests, which
+// are computed while downloading, as well as an error slice of the same
+// length as the URL list. URLs resulting in the same file name as a previous
+// one will fail. Partially downloaded files get removed on failure.
+func (a *Agent) DownloadAll(urls []string, destDir string) (map[string]string, []error) {
+	digests := map[string]string{}
+	errs := make([]error, len(urls))
+	names := make([]string, len(urls))
+	jobs := []int{}
+	seen := map[string]int{}
+
+	for i, u := range urls {
+		name, err := a.downloadFileName(u)
+		if err != nil {
+			errs[i] = err
 
-	for i, r := range resps {
-		if r == nil {
 			continue
 		}
 
-		var err error
-		if len(w) == 1 {
-			err = a.readResponse(r, w[0])
-		} else {
-			if i >= len(w) {
-				err = fmt.Errorf("request %d has no writer defined", i)
-			} else {
-				err = a.readResponse(r, w[i])
+		if j, ok := seen[name]; ok {
+			errs[i] = fmt.Errorf(
+				"file name %s of %s is already used by URL #%d", name, a.options.redactURL(u), j,
+			)
+
+			continue
+		}
+
+		seen[name] = i
+		names[i] = name
+		jobs = append(jobs, i)
+	}
+
+	if len(jobs) == 0 {
+		return digests, errs
+	}
+
+	if err := os.MkdirAll(destDir, os.FileMode(0o755)); err != nil {
+		for _, i := range jobs {
+			errs[i] = fmt.Errorf("create destination directory: %w", err)
+		}
+
+		return digests, errs
+	}
+
+	//nolint:gosec // integer overflow highly unlikely
+	t := throttler.New(int(a.options.MaxParallel), len(jobs))
+	m := sync.Mutex{}
+
+	for _, i := range jobs {
+		go func(url, name string) {
+			digest, err := a.downloadFile(url, filepath.Join(destDir, name), a.groupProgress(i))
+
+			m.Lock()
+			if err == nil {
+				digests[name] = digest
 			}
+
+			errs[i] = err
+			m.Unlock()
+
+			t.Done(err)
+		}(urls[i], names[i])
+		t.Throttle()
+	}
+
+	return digests, errs
+}
+
+// GetToFileWithChecksum downloads the URL to path while computing its SHA256
+// digest, which is expected to match the hex encoded expectedSHA256. The file
+// gets removed if the download fails or if the digest does not match, where
+// the latter results in an ErrChecksumMismatch.
+func (a *Agent) GetToFileWithChecksum(path, url, expectedSHA256 string) error {
+	digest, err := a.downloadFile(url, path, a.options.ProgressCallback)
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(digest, strings.TrimSpace(expectedSHA256)) {
+		if removeErr := os.Remove(path); removeErr != nil {
+			logrus.Warnf("Unable to remove download %s: %v", path, removeErr)
 		}
 
-		if err != nil {
-			errs[i] = fmt.Errorf("writing group response #%d: %w", i, err)
+		return fmt.Errorf(
+			"%w for %s: expected SHA256 %s but got %s",
+			ErrChecksumMismatch, a.options.redactURL(url), expectedSHA256, digest,
+		)
+	}
 
-			continue
+	return nil
+}
+
+// ExtractRemoteTarball downloads the gzipped tarball from the URL and extracts
+// it into destDir while downloading, without storing the tarball on disk. The
+// path sanitization of the tar package applies, as well as the maximum body
+// size of the agent and the provided tar options, like tar.WithMaxSize to
+// limit the extracted size.
+//
+// Retries only apply to establishing the connection until the response
+// headers have been received. Failures while streaming the body are not
+// retried, because the extraction cannot be resumed. In that case, destDir may
+// contain partially extracted files.
+func (a *Agent) ExtractRemoteTarball(url, destDir string, opts ...tar.Option) error {
+	return a.ExtractRemoteTarballWithContext(context.Background(), url, destDir, opts...)
+}
+
+// ExtractRemoteTarballWithContext behaves like ExtractRemoteTarball but binds
+// the request to the provided context.
+func (a *Agent) ExtractRemoteTarballWithContext(
+	ctx context.Context, url, destDir string, opts ...tar.Option,
+) error {
+	response, err := a.GetRequestWithContext(ctx, url)
+	if err != nil {
+		return fmt.Errorf("getting tarball: %w", err)
+	}
+	defer response.Body.Close()
+
+	// Extracting an error page is never useful, regardless of FailOnHTTPError
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return newHTTPError(response)
+	}
+
+	body := io.Reader(response.Body)
+
+	if progress := a.options.ProgressCallback; progress != nil {
+		body = io.TeeReader(body, &progressWriter{
+			w: io.Discard, total: response.ContentLength, callback: progress,
+		})
+	}
+
+	limited := &io.LimitedReader{R: body, N: math.MaxInt64}
+	if a.options.MaxBodySize > 0 {
+		limited.N = a.options.MaxBodySize
+	}
+
+	extractErr := tar.ExtractFromReader(limited, destDir, opts...)
+
+	// Probe for a single byte exceeding the limit.
+	if limited.N == 0 {
+		if n, _ := io.CopyN(io.Discard, body, 1); n > 0 {
+			return fmt.Errorf("%w: exceeds %d bytes", ErrBodyTooLarge, a.options.MaxBodySize)
 		}
 	}
 
-	return errs
+	if extractErr != nil {
+		return fmt.Errorf("extracting tarball from %s: %w", a.options.redactURL(url), extractErr)
+	}
+
+	return nil
+}
+
+// downloadFileName returns the file name for the provided download URL.
+func (a *Agent) downloadFileName(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		// The url.Error contains the raw URL
+		urlErr := &url.Error{}
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+
+		return "", fmt.Errorf("parse URL %s: %w", a.options.redactURL(rawURL), err)
+	}
+
+	name := path.Base(u.Path)
+	if name == "." || name == "/" || name == ".." {
+		return "", fmt.Errorf("unable to get file name from URL %s", a.options.redactURL(rawURL))
+	}
+
+	return name, nil
+}
+
+// downloadFile downloads the URL to dest and returns its SHA256 digest.
+func (a *Agent) downloadFile(url, dest string, progress func(written, total int64)) (digest string, err error) {
+	f, err := os.Create(dest)
+	if err != nil {
+		return "", fmt.Errorf("create file %s: %w", dest, err)
+	}
+
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close file %s: %w", dest, closeErr)
+		}
+
+		if err != nil {
+			if removeErr := os.Remove(dest); removeErr != nil {
+				logrus.Warnf("Unable to remove partial download %s: %v", dest, removeErr)
+			}
+		}
+	}()
+
+	hasher := sha256.New()
+	if err := a.getToWriter(context.Background(), io.MultiWriter(f, hasher), url, progress); err != nil {
+		return "", fmt.Errorf("download %s: %w", a.options.redactURL(url), a.options.redactURLError(err, url))
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
@@ -17,11 +17,18 @@ limitations under the License.
 package http_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -160,3 +167,293 @@ func TestPostRequest(t *testing.T) {
 		tc.assert(agent.PostRequest("", nil))
 	}
 }
+
+func TestPutRequest(t *testing.T) {
+	for _, tc := range map[string]struct {
+		prepare func(*httpfakes.FakeAgentImplementation)
+		assert  func(*http.Response, error)
+	}{
+		"should succeed": {
+			prepare: func(mock *httpfakes.FakeAgentImplementation) {
+				mock.SendPutRequestReturns(&http.Response{StatusCode: http.StatusOK}, nil)
+			},
+			assert: func(response *http.Response, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusOK, response.StatusCode)
+			},
+		},
+		"should succeed on retry": {
+			prepare: func(mock *httpfakes.FakeAgentImplementation) {
+				mock.SendPutRequestReturnsOnCall(0, &http.Response{StatusCode: http.StatusInternalServerError}, nil)
+				mock.SendPutRequestReturnsOnCall(1, &http.Response{StatusCode: http.StatusOK}, nil)
+			},
+			assert: func(response *http.Response, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusOK, response.StatusCode)
+			},
+		},
+		"should retry on URL error": {
+			prepare: func(mock *httpfakes.FakeAgentImplementation) {
+				mock.SendPutRequestReturns(nil, &url.Error{Err: errors.New("test")})
+			},
+			assert: func(response *http.Response, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "test")
+				assert.Nil(t, response)
+			},
+		},
+	} {
+		agent := rhttp.NewAgent().WithWaitTime(0)
+		mock := &httpfakes.FakeAgentImplementation{}
+		agent.SetImplementation(mock)
+
+		if tc.prepare != nil {
+			tc.prepare(mock)
+		}
+
+		//nolint:bodyclose // no need to close for mocked tests
+		tc.assert(agent.PutRequest("", nil))
+	}
+}
+
+func TestDelete(t *testing.T) {
+	for _, tc := range map[string]struct {
+		prepare func(*httpfakes.FakeAgentImplementation)
+		assert  func([]byte, error)
+	}{
+		"should succeed": {
+			prepare: func(mock *httpfakes.FakeAgentImplementation) {
+				mock.SendDeleteRequestReturns(&http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("deleted")),
+				}, nil)
+			},
+			assert: func(content []byte, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "deleted", string(content))
+			},
+		},
+		"should fail on HTTP error": {
+			prepare: func(mock *httpfakes.FakeAgentImplementation) {
+				mock.SendDeleteRequestReturns(&http.Response{
+					StatusCode: http.StatusNotFound,
+					Status:     "404 Not Found",
+					Body:       io.NopCloser(strings.NewReader("")),
+					Request:    &http.Request{},
+				}, nil)
+			},
+			assert: func(content []byte, err error) {
+				require.Error(t, err)
+				assert.Nil(t, content)
+			},
+		},
+		"should retry on URL error": {
+			prepare: func(mock *httpfakes.FakeAgentImplementation) {
+				mock.SendDeleteRequestReturns(nil, &url.Error{Err: errors.New("test")})
+			},
+			assert: func(content []byte, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "test")
+				assert.Nil(t, content)
+			},
+		},
+	} {
+		agent := rhttp.NewAgent().WithWaitTime(0)
+		mock := &httpfakes.FakeAgentImplementation{}
+		agent.SetImplementation(mock)
+
+		if tc.prepare != nil {
+			tc.prepare(mock)
+		}
+
+		tc.assert(agent.Delete(""))
+	}
+}
+
+func TestLogAsCurl(t *testing.T) {
+	var buf bytes.Buffer
+
+	logrus.SetOutput(&buf)
+	logrus.SetLevel(logrus.DebugLevel)
+
+	defer func() {
+		logrus.SetOutput(os.Stderr)
+		logrus.SetLevel(logrus.InfoLevel)
+	}()
+
+	mock := &httpfakes.FakeAgentImplementation{}
+	mock.SendPostRequestReturns(&http.Response{StatusCode: http.StatusOK}, nil)
+
+	agent := rhttp.NewAgent().WithLogAsCurl(true)
+	agent.SetImplementation(mock)
+
+	defer agent.WithLogAsCurl(false)
+
+	//nolint:bodyclose // no need to close for mocked tests
+	_, err := agent.PostRequest(
+		"https://example.com/test",
+		[]byte("it's 0123456789abcdef0123456789abcdef01234567:x-oauth-basic"),
+	)
+	require.NoError(t, err)
+
+	_, out, found := strings.Cut(buf.String(), "Request as curl command: ")
+	require.True(t, found)
+	require.Contains(t, out, "curl -X POST -H 'Content-Type: application/octet-stream'")
+	require.Contains(t, out, `-d 'it'\\''s __SANITIZED__:x-oauth-basic'`)
+	require.Contains(t, out, "'https://example.com/test'")
+	require.NotContains(t, out, "0123456789abcdef0123456789abcdef01234567")
+}
+
+func TestURLRedaction(t *testing.T) {
+	var buf bytes.Buffer
+
+	logrus.SetOutput(&buf)
+	logrus.SetLevel(logrus.DebugLevel)
+
+	defer func() {
+		logrus.SetOutput(os.Stderr)
+		logrus.SetLevel(logrus.InfoLevel)
+	}()
+
+	mock := &httpfakes.FakeAgentImplementation{}
+	mock.SendGetRequestReturns(&http.Response{StatusCode: http.StatusOK}, nil)
+
+	agent := rhttp.NewAgentWithOptions()
+	agent.SetImplementation(mock)
+
+	//nolint:bodyclose // no need to close for mocked tests
+	_, err := agent.GetRequest("https://example.com/test?page=2&token=s3cr3t")
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "https://example.com/test?page=2&token=__SANITIZED__")
+	require.NotContains(t, buf.String(), "s3cr3t")
+
+	buf.Reset()
+
+	//nolint:bodyclose // no need to close for mocked tests
+	_, err = agent.WithURLRedaction(false).GetRequest("https://example.com/test?token=s3cr3t")
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "https://example.com/test?token=s3cr3t")
+}
+
+func TestGetRequestWithContext(t *testing.T) {
+	mock := &httpfakes.FakeAgentImplementation{}
+	mock.SendGetRequestReturns(&http.Response{StatusCode: http.StatusInternalServerError}, nil)
+
+	agent := rhttp.NewAgentWithOptions(rhttp.WithWaitTimeOpt(time.Hour))
+	agent.SetImplementation(mock)
+
+	// Already canceled contexts do not send any request
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	//nolint:bodyclose // no need to close for mocked tests
+	_, err := agent.GetRequestWithContext(ctx, "")
+	require.ErrorIs(t, err, context.Canceled)
+	require.Zero(t, mock.SendGetRequestCallCount())
+
+	// Cancellation stops the retry backoff
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	//nolint:bodyclose // no need to close for mocked tests
+	_, err = agent.GetRequestWithContext(ctx, "")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), 5*time.Second)
+	require.Equal(t, 1, mock.SendGetRequestCallCount())
+}
+
+func TestHeadRequestWithContext(t *testing.T) {
+	mock := &httpfakes.FakeAgentImplementation{}
+	mock.SendHeadRequestReturns(nil, errors.New("test"))
+
+	agent := rhttp.NewAgentWithOptions()
+	agent.SetImplementation(mock)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	//nolint:bodyclose // no need to close for mocked tests
+	_, err := agent.HeadRequestWithContext(ctx, "")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestGetRequestGroupWithContext(t *testing.T) {
+	mock := &httpfakes.FakeAgentImplementation{}
+	mock.SendGetRequestReturns(&http.Response{StatusCode: http.StatusOK}, nil)
+
+	agent := rhttp.NewAgentWithOptions(rhttp.WithMaxParallelOpt(1))
+	agent.SetImplementation(mock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	//nolint:bodyclose // no need to close for mocked tests
+	resps, errs := agent.GetRequestGroupWithContext(ctx, []string{"a", "b", "c"})
+	require.Len(t, resps, 3)
+	require.Len(t, errs, 3)
+
+	for _, err := range errs {
+		require.ErrorIs(t, err, context.Canceled)
+	}
+
+	require.Zero(t, mock.SendGetRequestCallCount())
+
+	_, errs = agent.PostGroupWithContext(ctx, []string{"a", "b"}, [][]byte{nil, nil})
+	for _, err := range errs {
+		require.ErrorIs(t, err, context.Canceled)
+	}
+
+	require.Zero(t, mock.SendPostRequestCallCount())
+}
+
+func TestWithRetryableStatusCodes(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		codes         []int
+		status        int
+		expectedCalls int
+	}{
+		{name: "408 by default", status: http.StatusRequestTimeout, expectedCalls: 1},
+		{name: "429 by default", status: http.StatusTooManyRequests, expectedCalls: 3},
+		{name: "408 if set", codes: []int{http.StatusRequestTimeout}, status: http.StatusRequestTimeout, expectedCalls: 3},
+		{name: "429 if not set", codes: []int{http.StatusRequestTimeout}, status: http.StatusTooManyRequests, expectedCalls: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := &httpfakes.FakeAgentImplementation{}
+			mock.SendGetRequestReturns(&http.Response{StatusCode: tc.status}, nil)
+			mock.SendHeadRequestReturns(&http.Response{StatusCode: tc.status}, nil)
+
+			agent := rhttp.NewAgentWithOptions(rhttp.WithRetriesOpt(3), rhttp.WithWaitTimeOpt(0))
+			if tc.codes != nil {
+				agent.WithRetryableStatusCodes(tc.codes...)
+			}
+
+			agent.SetImplementation(mock)
+
+			//nolint:bodyclose // no need to close for mocked tests
+			_, err := agent.GetRequest("")
+			require.Equal(t, tc.expectedCalls, mock.SendGetRequestCallCount())
+			require.Equal(t, tc.expectedCalls > 1, err != nil)
+		})
+	}
+}
+
+func TestWithRetryableStatusCodesHead(t *testing.T) {
+	mock := &httpfakes.FakeAgentImplementation{}
+	mock.SendHeadRequestReturnsOnCall(0, &http.Response{StatusCode: http.StatusRequestTimeout}, nil)
+	mock.SendHeadRequestReturnsOnCall(1, &http.Response{StatusCode: http.StatusOK}, nil)
+
+	agent := rhttp.NewAgentWithOptions(rhttp.WithRetryableStatusCodesOpt(http.StatusRequestTimeout))
+	agent.SetImplementation(mock)
+
+	//nolint:bodyclose // no need to close for mocked tests
+	res, err := agent.HeadRequest("")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, 2, mock.SendHeadRequestCallCount())
+}
@@ -19,7 +19,7 @@ Package http provides a configurable agent to talk to http servers.
 
 # Function Families
 
-It provides three families of functions for the GET, POST and HEAD methods
+It provides families of functions for the GET, POST, PUT and HEAD methods
 that return the raw http.Response, the response contents as a byte slice or to
 write the response to a writer.
 
@@ -40,6 +40,14 @@ but in plural form, ie same type but a slice:
 	PostRequestGroup([]string urls, [][]byte postData) ([]*http.Response, []error)
 	PostToWriterGroup([]io.Writer w, []string urls, [][]byte postData) []error
 
+The functions for the PUT method mirror the POST ones, including the content
+type sent along with the data. The DELETE method is supported by Delete,
+DeleteRequest and DeleteRequestGroup.
+
+To avoid loading large payloads into memory, PostReader and PostReaderRequest
+stream the POST data from an io.Reader. Those requests are only retried if the
+reader also implements io.Seeker.
+
 # Group Requests
 
 All the _Group_ families perform the requests in parallel. The number of
@@ -59,6 +67,18 @@ function comes in handy:
 	   // Handle errors here
 	}
 
+# Retries
+
+Failed requests are retried with an exponential backoff. The value set via
+.WithRetries(uint) is the total number of attempts per request, including the
+first one, and not the number of additional retries:
+
+	# Attempt every request up to three times:
+	agent := http.NewAgent().WithRetries(3)
+
+A value of zero behaves like one, which means that the request is attempted
+exactly once. Use .WithNoRetries() to express that explicitly.
+
 # Single and Multiple Writer Output
 
 The ToWriterGroup variants take a list of writers in their first arguments.
@@ -17,19 +17,32 @@ limitations under the License.
 package http_test
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	khttp "sigs.k8s.io/release-utils/http"
 	"sigs.k8s.io/release-utils/http/httpfakes"
+	ktar "sigs.k8s.io/release-utils/tar"
 )
 
 func TestGetURLResponseSuccess(t *testing.T) {
@@ -416,3 +429,1031 @@ func TestAgentPostRequestGroup(t *testing.T) {
 		})
 	}
 }
+
+func TestAgentPostRequestGroupWithContentTypes(t *testing.T) {
+	t.Parallel()
+
+	fake := &httpfakes.FakeAgentImplementation{}
+	fake.SendPostRequestCalls(func(_ *http.Client, _ string, data []byte, contentType string) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(contentType + " " + string(data))),
+			Request:    &http.Request{},
+		}, nil
+	})
+
+	agent := NewTestAgent().WithRetries(0)
+	agent.SetImplementation(fake)
+
+	//nolint: bodyclose // closed by closeHTTPResponseGroup
+	resps, errs := agent.PostRequestGroupWithContentTypes(
+		[]string{"fake:manifest", "fake:asset"},
+		[][]byte{[]byte("{}"), []byte("bin")},
+		[]string{"application/json", "application/octet-stream"},
+	)
+	defer closeHTTPResponseGroup(resps)
+
+	require.NoError(t, errors.Join(errs...))
+
+	for i, expected := range []string{"application/json {}", "application/octet-stream bin"} {
+		content, err := io.ReadAll(resps[i].Body)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(content))
+	}
+
+	// All slices are required to be of equal length
+	for _, contentTypes := range [][]string{nil, {"application/json"}} {
+		//nolint: bodyclose // no responses are returned
+		resps, errs := agent.PostRequestGroupWithContentTypes(
+			[]string{"fake:manifest", "fake:asset"}, make([][]byte, 2), contentTypes,
+		)
+		require.Equal(t, []*http.Response{nil, nil}, resps)
+
+		for _, err := range errs {
+			require.ErrorContains(t, err, "same number URLs and POST content types required")
+		}
+	}
+}
+
+func TestAgentGetText(t *testing.T) {
+	for _, tc := range []struct {
+		n           string
+		contentType string
+		body        []byte
+		expected    string
+		mustErr     bool
+	}{
+		{n: "no content type", body: []byte("café"), expected: "café"},
+		{n: "no charset", contentType: "text/plain", body: []byte("café"), expected: "café"},
+		{n: "utf-8", contentType: "text/plain; charset=UTF-8", body: []byte("café"), expected: "café"},
+		{n: "iso-8859-1", contentType: "text/plain; charset=ISO-8859-1", body: []byte("caf\xe9"), expected: "café"},
+		{n: "windows-1252", contentType: "text/html; charset=windows-1252", body: []byte("\x80"), expected: "€"},
+		{n: "unknown charset", contentType: "text/plain; charset=wrong", body: []byte("café"), mustErr: true},
+	} {
+		t.Run(tc.n, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, _ *http.Request) {
+					w.Header()["Content-Type"] = []string{tc.contentType}
+					if _, err := w.Write(tc.body); err != nil {
+						t.Fail()
+					}
+				}))
+			defer server.Close()
+
+			res, err := khttp.NewAgent().GetText(server.URL)
+			if tc.mustErr {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, res)
+		})
+	}
+}
+
+func TestAgentGetJSONInto(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			_, err := io.WriteString(w, `{"data":{"items":[{"name":"first","size":1},{"name":"second","size":2}]}}`)
+			if err != nil {
+				t.Fail()
+			}
+		}))
+	defer server.Close()
+
+	type item struct {
+		Name string `json:"name"`
+		Size int    `json:"size"`
+	}
+
+	agent := khttp.NewAgent()
+
+	var name string
+	require.NoError(t, agent.GetJSONInto(server.URL, &name, "data.items.0.name"))
+	require.Equal(t, "first", name)
+
+	var second item
+	require.NoError(t, agent.GetJSONInto(server.URL, &second, "data.items.1"))
+	require.Equal(t, item{Name: "second", Size: 2}, second)
+
+	var items []item
+	require.NoError(t, agent.GetJSONInto(server.URL, &items, "data.items"))
+	require.Len(t, items, 2)
+
+	var whole map[string]any
+	require.NoError(t, agent.GetJSONInto(server.URL, &whole, ""))
+	require.Contains(t, whole, "data")
+
+	for _, path := range []string{"data.missing", "data.items.2", "data.items.name", "data.items.0.name.x"} {
+		require.Error(t, agent.GetJSONInto(server.URL, &name, path), path)
+	}
+
+	require.Error(t, agent.GetJSONInto(server.URL, &name, "data"))
+}
+
+func TestAgentExpectedContentType(t *testing.T) {
+	for _, tc := range []struct {
+		n           string
+		contentType string
+		expected    string
+		mustErr     bool
+	}{
+		{n: "no expectation", contentType: "text/html", expected: ""},
+		{n: "match", contentType: "application/json", expected: "application/json"},
+		{n: "match with charset", contentType: "Application/JSON; charset=utf-8", expected: "application/json"},
+		{n: "match prefix", contentType: "application/json", expected: "application/"},
+		{n: "mismatch", contentType: "text/html; charset=utf-8", expected: "application/json", mustErr: true},
+	} {
+		t.Run(tc.n, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, _ *http.Request) {
+					w.Header().Set("Content-Type", tc.contentType)
+					if _, err := io.WriteString(w, "<html>error page</html>"); err != nil {
+						t.Fail()
+					}
+				}))
+			defer server.Close()
+
+			res, err := khttp.NewAgentWithOptions(khttp.WithExpectedContentTypeOpt(tc.expected)).
+				Get(server.URL)
+			if tc.mustErr {
+				require.ErrorIs(t, err, khttp.ErrUnexpectedContentType)
+				require.Contains(t, err.Error(), "<html>error page</html>")
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "<html>error page</html>", string(res))
+		})
+	}
+}
+
+func TestAgentDownloadAll(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, "missing") {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			if _, err := io.WriteString(w, "test"); err != nil {
+				t.Fail()
+			}
+		}))
+	defer server.Close()
+
+	destDir := filepath.Join(t.TempDir(), "dest")
+	urls := []string{
+		server.URL + "/v1.0.0/kubectl",
+		server.URL + "/v1.0.0/kubeadm",
+		server.URL + "/v1.0.0/missing",
+		server.URL + "/other/kubectl",
+		server.URL + "/",
+	}
+
+	digests, errs := khttp.NewAgentWithOptions().DownloadAll(urls, destDir)
+	require.Len(t, errs, len(urls))
+	require.NoError(t, errs[0])
+	require.NoError(t, errs[1])
+	require.Error(t, errs[2])
+	require.Error(t, errs[3])
+	require.Error(t, errs[4])
+
+	const expected = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	require.Equal(t, map[string]string{"kubectl": expected, "kubeadm": expected}, digests)
+
+	entries, err := os.ReadDir(destDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	content, err := os.ReadFile(filepath.Join(destDir, "kubectl"))
+	require.NoError(t, err)
+	require.Equal(t, "test", string(content))
+}
+
+func TestAgentGetToWriterWithContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(_ http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	var buf bytes.Buffer
+	err := khttp.NewAgentWithOptions(khttp.WithTimeoutOpt(time.Minute)).
+		GetToWriterWithContext(ctx, &buf, server.URL)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestAgentRespectRetryAfter(t *testing.T) {
+	for _, respect := range []bool{true, false} {
+		var calls int
+
+		server := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, _ *http.Request) {
+				calls++
+				if calls == 1 {
+					w.Header().Set("Retry-After", "2")
+					w.WriteHeader(http.StatusTooManyRequests)
+				}
+			}))
+
+		agent := khttp.NewAgentWithOptions(
+			khttp.WithWaitTimeOpt(10*time.Millisecond),
+			khttp.WithRespectRetryAfterOpt(respect),
+		)
+
+		start := time.Now()
+		_, err := agent.Get(server.URL)
+		elapsed := time.Since(start)
+
+		server.Close()
+
+		require.NoError(t, err)
+		require.Equal(t, 2, calls)
+
+		if respect {
+			require.GreaterOrEqual(t, elapsed, 2*time.Second)
+		} else {
+			require.Less(t, elapsed, time.Second)
+		}
+	}
+}
+
+func TestAgentRetries(t *testing.T) {
+	for _, tc := range []struct {
+		opts     []khttp.AgentOption
+		attempts int32
+	}{
+		{opts: []khttp.AgentOption{khttp.WithRetriesOpt(0)}, attempts: 1},
+		{opts: []khttp.AgentOption{khttp.WithRetriesOpt(1)}, attempts: 1},
+		{opts: []khttp.AgentOption{khttp.WithRetriesOpt(3)}, attempts: 3},
+		{opts: []khttp.AgentOption{khttp.WithRetriesOpt(3), khttp.WithNoRetriesOpt()}, attempts: 1},
+	} {
+		var calls atomic.Int32
+
+		server := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, _ *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+
+		agent := khttp.NewAgentWithOptions(append(
+			tc.opts,
+			khttp.WithWaitTimeOpt(time.Millisecond),
+			khttp.WithMaxWaitTimeOpt(time.Millisecond),
+		)...)
+
+		_, err := agent.Get(server.URL)
+		require.Error(t, err)
+		require.Equal(t, tc.attempts, calls.Load())
+
+		// HEAD requests use the same semantics, but the backoff cannot be
+		// configured, which is why only single attempts are tested
+		if tc.attempts == 1 {
+			calls.Store(0)
+
+			_, err = agent.Head(server.URL)
+			require.Error(t, err)
+			require.Equal(t, tc.attempts, calls.Load())
+		}
+
+		server.Close()
+	}
+}
+
+func TestAgentHeadInfo(t *testing.T) {
+	lastModified := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/missing" {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			if r.URL.Path == "/plain" {
+				w.Header()["Content-Type"] = nil
+				w.Header()["Date"] = nil
+
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/gzip")
+			w.Header().Set("Content-Length", "1234")
+			w.Header().Set("ETag", `"abc"`)
+			w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+			w.Header().Set("Accept-Ranges", "bytes")
+		}))
+	defer server.Close()
+
+	agent := khttp.NewAgentWithOptions()
+
+	info, err := agent.HeadInfo(server.URL + "/file.tar.gz")
+	require.NoError(t, err)
+	require.Equal(t, khttp.HeadInfo{
+		StatusCode:    http.StatusOK,
+		ContentLength: 1234,
+		ContentType:   "application/gzip",
+		ETag:          `"abc"`,
+		LastModified:  lastModified,
+		AcceptsRanges: true,
+	}, info)
+
+	info, err = agent.HeadInfo(server.URL + "/plain")
+	require.NoError(t, err)
+	require.Equal(t, khttp.HeadInfo{StatusCode: http.StatusOK}, info)
+
+	info, err = agent.HeadInfo(server.URL + "/missing")
+	require.Error(t, err)
+	require.Equal(t, http.StatusNotFound, info.StatusCode)
+}
+
+func TestAgentPostReader(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+		failures int
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Fail()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			requests++
+			if requests <= failures {
+				w.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+
+			fmt.Fprintf(w, "%d %s", r.ContentLength, body)
+		}))
+	defer server.Close()
+
+	reset := func(failing int) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests = 0
+		failures = failing
+	}
+
+	agent := khttp.NewAgentWithOptions(khttp.WithWaitTimeOpt(0), khttp.WithRetriesOpt(3))
+
+	// Seekable readers are rewound before retrying
+	reset(1)
+
+	res, err := agent.PostReader(server.URL, strings.NewReader("seekable"), 8)
+	require.NoError(t, err)
+	require.Equal(t, "8 seekable", string(res))
+	require.Equal(t, 2, requests)
+
+	// Non seekable readers are not retried
+	reset(1)
+
+	_, err = agent.PostReader(server.URL, io.MultiReader(strings.NewReader("once")), 4)
+	require.Error(t, err)
+	require.Equal(t, 1, requests)
+
+	// Unknown content length
+	reset(0)
+
+	res, err = agent.PostReader(server.URL, io.MultiReader(strings.NewReader("unknown")), -1)
+	require.NoError(t, err)
+	require.Equal(t, "-1 unknown", string(res))
+	require.Equal(t, 1, requests)
+}
+
+func TestAgentExtractRemoteTarball(t *testing.T) {
+	tarball := func(name, content string) []byte {
+		var buf bytes.Buffer
+
+		gw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gw)
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+		require.NoError(t, gw.Close())
+
+		return buf.Bytes()
+	}
+
+	valid := tarball("dir/file.txt", "content")
+	tainted := tarball("../evil.txt", "evil")
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var body []byte
+
+			switch r.URL.Path {
+			case "/valid.tar.gz":
+				body = valid
+			case "/tainted.tar.gz":
+				body = tainted
+			default:
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			if _, err := w.Write(body); err != nil {
+				t.Fail()
+			}
+		}))
+	defer server.Close()
+
+	agent := khttp.NewAgentWithOptions(khttp.WithRetriesOpt(1))
+
+	destDir := t.TempDir()
+	require.NoError(t, agent.ExtractRemoteTarball(server.URL+"/valid.tar.gz", destDir))
+
+	content, err := os.ReadFile(filepath.Join(destDir, "dir", "file.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "content", string(content))
+
+	// Path sanitization
+	destDir = filepath.Join(t.TempDir(), "dest")
+	err = agent.ExtractRemoteTarball(server.URL+"/tainted.tar.gz", destDir)
+	require.ErrorContains(t, err, "content filepath is tainted")
+	require.NoFileExists(t, filepath.Join(filepath.Dir(destDir), "evil.txt"))
+
+	// Size guards
+	err = agent.ExtractRemoteTarball(
+		server.URL+"/valid.tar.gz", t.TempDir(), ktar.WithMaxSize(3),
+	)
+	require.ErrorIs(t, err, ktar.ErrMaxSizeExceeded)
+
+	err = khttp.NewAgentWithOptions(khttp.WithMaxBodySizeOpt(10)).
+		ExtractRemoteTarball(server.URL+"/valid.tar.gz", t.TempDir())
+	require.ErrorIs(t, err, khttp.ErrBodyTooLarge)
+
+	// HTTP errors
+	err = agent.ExtractRemoteTarball(server.URL+"/missing.tar.gz", t.TempDir())
+	httpErr := &khttp.HTTPError{}
+	require.ErrorAs(t, err, &httpErr)
+	require.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+}
+
+func TestAgentPut(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPut {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Fail()
+			}
+
+			fmt.Fprintf(w, "%s %s", r.Header.Get("Content-Type"), body)
+		}))
+	defer server.Close()
+
+	agent := khttp.NewAgentWithOptions(khttp.WithPostContentTypeOpt("text/plain"))
+
+	res, err := agent.Put(server.URL, []byte("data"))
+	require.NoError(t, err)
+	require.Equal(t, "text/plain data", string(res))
+
+	var buf bytes.Buffer
+	require.NoError(t, agent.PutToWriter(&buf, server.URL, []byte("writer")))
+	require.Equal(t, "text/plain writer", buf.String())
+
+	contents, errs := agent.PutGroup(
+		[]string{server.URL, server.URL}, [][]byte{[]byte("a"), []byte("b")},
+	)
+	require.NoError(t, errors.Join(errs...))
+	require.Equal(t, [][]byte{[]byte("text/plain a"), []byte("text/plain b")}, contents)
+
+	buf.Reset()
+	errs = agent.PutToWriterGroup(
+		[]io.Writer{&buf}, []string{server.URL, server.URL}, [][]byte{[]byte("a"), []byte("b")},
+	)
+	require.NoError(t, errors.Join(errs...))
+	require.Equal(t, "text/plain atext/plain b", buf.String())
+
+	// URLs and payloads need to be of the same length
+	//nolint: bodyclose // no responses are returned
+	_, errs = agent.PutRequestGroup([]string{server.URL, server.URL}, [][]byte{nil})
+	require.Len(t, errs, 2)
+
+	for _, err := range errs {
+		require.ErrorContains(t, err, "same number URLs and PUT payloads required")
+	}
+}
+
+func TestAgentDeleteRequestGroup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodDelete {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+
+				return
+			}
+
+			if r.URL.Path == "/missing" {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	defer server.Close()
+
+	agent := khttp.NewAgentWithOptions(khttp.WithMaxParallelOpt(2), khttp.WithRetriesOpt(1))
+
+	//nolint: bodyclose // closed below
+	resps, errs := agent.DeleteRequestGroup([]string{
+		server.URL + "/a", server.URL + "/missing", "invalid://url",
+	})
+	defer func() {
+		for _, resp := range resps {
+			if resp != nil {
+				resp.Body.Close()
+			}
+		}
+	}()
+
+	require.Len(t, resps, 3)
+	require.Len(t, errs, 3)
+	require.NoError(t, errs[0])
+	require.Equal(t, http.StatusOK, resps[0].StatusCode)
+	require.NoError(t, errs[1])
+	require.Equal(t, http.StatusNotFound, resps[1].StatusCode)
+	require.Error(t, errs[2])
+}
+
+func TestAgentGetRequestGroupWithTimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(_ http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/slow" {
+				time.Sleep(500 * time.Millisecond)
+			}
+		}))
+	defer server.Close()
+
+	agent := khttp.NewAgentWithOptions(khttp.WithTimeoutOpt(100 * time.Millisecond))
+	urls := []string{server.URL + "/fast", server.URL + "/slow", server.URL + "/slow"}
+
+	//nolint: bodyclose // closed below
+	resps, errs := agent.GetRequestGroupWithTimeouts(urls, []time.Duration{0, 5 * time.Second, 0})
+	defer closeHTTPResponseGroup(resps)
+
+	require.Len(t, errs, 3)
+	require.NoError(t, errs[0])
+	require.Equal(t, http.StatusOK, resps[0].StatusCode)
+	require.NoError(t, errs[1])
+	require.Equal(t, http.StatusOK, resps[1].StatusCode)
+	require.Error(t, errs[2], "slow request without timeout override should fail")
+
+	// No timeouts use the default one of the agent
+	//nolint: bodyclose // closed below
+	resps, errs = agent.GetRequestGroupWithTimeouts(urls[:2], nil)
+	defer closeHTTPResponseGroup(resps)
+
+	require.NoError(t, errs[0])
+	require.Error(t, errs[1])
+
+	// Mismatching lengths fail all requests
+	//nolint: bodyclose // no responses
+	resps, errs = agent.GetRequestGroupWithTimeouts(urls, []time.Duration{time.Second})
+	require.Len(t, resps, 3)
+
+	for i := range urls {
+		require.Nil(t, resps[i])
+		require.Error(t, errs[i])
+	}
+}
+
+func TestAgentPostStreamThreshold(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("TMPDIR", tmpDir)
+
+	var calls int
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			calls++
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Fail()
+			}
+
+			if calls == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+
+			fmt.Fprintf(w, "%d %s %s", r.ContentLength, r.Header.Get("Content-Type"), body)
+		}))
+	defer server.Close()
+
+	agent := khttp.NewAgentWithOptions(
+		khttp.WithStreamThresholdOpt(4),
+		khttp.WithWaitTimeOpt(time.Millisecond),
+		khttp.WithPostContentTypeOpt("text/plain"),
+	)
+
+	// The body gets streamed again on retry
+	res, err := agent.Post(server.URL, []byte("streamed"))
+	require.NoError(t, err)
+	require.Equal(t, "8 text/plain streamed", string(res))
+	require.Equal(t, 2, calls)
+
+	res, err = agent.Post(server.URL, []byte("mem"))
+	require.NoError(t, err)
+	require.Equal(t, "3 text/plain mem", string(res))
+
+	entries, err := os.ReadDir(tmpDir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestAgentHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+	defer server.Close()
+
+	agent := khttp.NewAgentWithOptions(khttp.WithFailOnHTTPErrorOpt(true))
+	expected := &khttp.HTTPError{
+		StatusCode: http.StatusForbidden,
+		Status:     "403 Forbidden",
+		URL:        server.URL + "/file",
+	}
+
+	_, err := agent.Get(server.URL + "/file")
+	require.Error(t, err)
+
+	httpErr := &khttp.HTTPError{}
+	require.ErrorAs(t, err, &httpErr)
+	require.Equal(t, expected, httpErr)
+	require.Contains(t, err.Error(), "HTTP error 403 Forbidden for "+server.URL+"/file")
+
+	_, err = agent.HeadInfo(server.URL + "/file")
+	require.ErrorAs(t, err, &httpErr)
+	require.Equal(t, expected, httpErr)
+
+	// Credentials are redacted from the URL
+	const secret = "s3cr3t"
+
+	credentialed := strings.Replace(server.URL, "://", "://user:"+secret+"@", 1) +
+		"/file?token=" + secret
+
+	for _, get := range []func(string) error{
+		func(url string) error {
+			_, err := agent.Get(url)
+
+			return err
+		},
+		func(url string) error {
+			_, err := agent.HeadInfo(url)
+
+			return err
+		},
+	} {
+		err = get(credentialed)
+		require.ErrorAs(t, err, &httpErr)
+		require.NotContains(t, httpErr.URL, secret)
+		require.NotContains(t, err.Error(), secret)
+		require.Contains(t, httpErr.URL, "token=__SANITIZED__")
+	}
+}
+
+func TestAgentAuth(t *testing.T) {
+	const token = "s3cr3t-t0k3n"
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if _, err := w.Write([]byte(r.Header.Get("Authorization"))); err != nil {
+				t.Fail()
+			}
+		}))
+	defer server.Close()
+
+	res, err := khttp.NewAgentWithOptions(khttp.WithBasicAuthOpt("user", "pass")).Get(server.URL)
+	require.NoError(t, err)
+	require.Equal(t, "Basic dXNlcjpwYXNz", string(res))
+
+	agent := khttp.NewAgentWithOptions(khttp.WithBearerTokenOpt(token))
+
+	res, err = agent.Get(server.URL)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer "+token, string(res))
+
+	res, err = agent.Post(server.URL, []byte{})
+	require.NoError(t, err)
+	require.Equal(t, "Bearer "+token, string(res))
+
+	// Credentials are never shared between agents
+	res, err = khttp.NewAgent().WithBearerToken(token).Get(server.URL)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer "+token, string(res))
+
+	res, err = khttp.NewAgent().WithBasicAuth("user", "pass").Get(server.URL)
+	require.NoError(t, err)
+	require.Equal(t, "Basic dXNlcjpwYXNz", string(res))
+
+	res, err = khttp.NewAgent().Get(server.URL)
+	require.NoError(t, err)
+	require.Empty(t, string(res))
+
+	// Credentials are redacted from errors
+	unreachable := "http://user:" + token + "@127.0.0.1:1/" + token
+
+	_, err = agent.WithRetries(1).GetRequest(unreachable) //nolint:bodyclose // no response
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), token)
+	require.Contains(t, err.Error(), "__SANITIZED__")
+
+	_, errs := agent.PostRequestGroup([]string{unreachable}, [][]byte{{}}) //nolint:bodyclose // no response
+	require.Len(t, errs, 1)
+	require.Error(t, errs[0])
+	require.NotContains(t, errs[0].Error(), token)
+
+	// URL credentials are redacted from download errors without configured
+	// credentials as well
+	credentialed := "http://user:" + token + "@127.0.0.1:1"
+
+	_, errs = khttp.NewAgentWithOptions(khttp.WithRetriesOpt(1)).DownloadAll([]string{
+		credentialed + "/file?token=" + token,
+		credentialed + "/file?token=" + token,
+		credentialed + "/?token=" + token,
+		credentialed + "/%zz?token=" + token,
+	}, t.TempDir())
+	require.Len(t, errs, 4)
+
+	for _, err := range errs {
+		require.Error(t, err)
+		require.NotContains(t, err.Error(), token)
+	}
+}
+
+func TestAgentMaxBodySize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			size := 10
+			if r.URL.Path == "/large" {
+				size = 1 << 20
+			}
+
+			// The client may hang up early when exceeding the limit
+			//nolint:errcheck // see above
+			w.Write(bytes.Repeat([]byte("a"), size))
+		}))
+	defer server.Close()
+
+	agent := khttp.NewAgentWithOptions(khttp.WithMaxBodySizeOpt(10))
+
+	res, err := agent.Get(server.URL + "/small")
+	require.NoError(t, err)
+	require.Len(t, res, 10)
+
+	_, err = agent.Get(server.URL + "/large")
+	require.ErrorIs(t, err, khttp.ErrBodyTooLarge)
+
+	var buf bytes.Buffer
+	err = agent.GetToWriter(&buf, server.URL+"/large")
+	require.ErrorIs(t, err, khttp.ErrBodyTooLarge)
+	require.Equal(t, 10, buf.Len())
+
+	// No limit by default
+	res, err = khttp.NewAgentWithOptions().Get(server.URL + "/large")
+	require.NoError(t, err)
+	require.Len(t, res, 1<<20)
+}
+
+func TestAgentAutoDecompress(t *testing.T) {
+	const content = "hello compressed world"
+
+	var gzipped, deflated bytes.Buffer
+
+	gw := gzip.NewWriter(&gzipped)
+	_, err := gw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	fw, err := flate.NewWriter(&deflated, flate.DefaultCompression)
+	require.NoError(t, err)
+	_, err = fw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, fw.Close())
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			body := []byte(content)
+
+			switch r.URL.Path {
+			case "/gzip":
+				w.Header().Set("Content-Encoding", "gzip")
+				body = gzipped.Bytes()
+			case "/deflate":
+				w.Header().Set("Content-Encoding", "deflate")
+				body = deflated.Bytes()
+			}
+
+			if _, err := w.Write(body); err != nil {
+				t.Fail()
+			}
+		}))
+	defer server.Close()
+
+	// Setting the Accept-Encoding header manually disables the transparent
+	// decompression of the default transport.
+	agent := khttp.NewAgentWithOptions(
+		khttp.WithHeaderOpt("Accept-Encoding", "gzip, deflate"),
+		khttp.WithAutoDecompressOpt(true),
+	)
+
+	var res []byte
+
+	for _, path := range []string{"/gzip", "/deflate", "/plain"} {
+		res, err = agent.Get(server.URL + path)
+		require.NoError(t, err, path)
+		require.Equal(t, content, string(res), path)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, agent.GetToWriter(&buf, server.URL+"/gzip"))
+	require.Equal(t, content, buf.String())
+
+	// Compressed bytes are returned if disabled
+	res, err = agent.WithAutoDecompress(false).Get(server.URL + "/gzip")
+	require.NoError(t, err)
+	require.Equal(t, gzipped.Bytes(), res)
+}
+
+func TestAgentContentLengthGroup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+
+				return
+			}
+
+			switch r.URL.Path {
+			case "/missing":
+				w.WriteHeader(http.StatusNotFound)
+			case "/small":
+				w.Header().Set("Content-Length", "10")
+			case "/large":
+				w.Header().Set("Content-Length", "12345")
+			}
+		}))
+	defer server.Close()
+
+	urls := []string{server.URL + "/large", server.URL + "/missing", server.URL + "/small"}
+
+	lengths, errs := khttp.NewAgentWithOptions(khttp.WithMaxParallelOpt(2)).ContentLengthGroup(urls)
+	require.Equal(t, []int64{12345, -1, 10}, lengths)
+	require.NoError(t, errs[0])
+	require.NoError(t, errs[2])
+
+	httpErr := &khttp.HTTPError{}
+	require.ErrorAs(t, errs[1], &httpErr)
+	require.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+
+	//nolint: bodyclose // closed below
+	resps, errs := khttp.NewAgentWithOptions().HeadRequestGroup(urls)
+	defer closeHTTPResponseGroup(resps)
+
+	require.NoError(t, errors.Join(errs...))
+	require.Equal(t, http.StatusOK, resps[0].StatusCode)
+	require.Equal(t, http.StatusNotFound, resps[1].StatusCode)
+	require.Equal(t, http.StatusOK, resps[2].StatusCode)
+}
+
+func TestAgentRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	agent := khttp.NewAgentWithOptions(khttp.WithRateLimitOpt(20), khttp.WithMaxParallelOpt(5))
+
+	// The first request fires immediately, the others every 50ms
+	start := time.Now()
+	_, errs := agent.GetGroup(slices.Repeat([]string{server.URL}, 5))
+	require.NoError(t, errors.Join(errs...))
+	require.GreaterOrEqual(t, time.Since(start), 190*time.Millisecond)
+
+	// Waiting for the rate limit respects the context
+	agent.WithRateLimit(0.1)
+
+	_, err := agent.Get(server.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start = time.Now()
+	_, err = agent.GetWithContext(ctx, server.URL)
+	require.Error(t, err)
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestAgentGetToFileWithChecksum(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			if _, err := io.WriteString(w, "hello sig-release!"); err != nil {
+				t.Fail()
+			}
+		}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	agent := khttp.NewAgentWithOptions()
+
+	sum := sha256.Sum256([]byte("hello sig-release!"))
+	expected := hex.EncodeToString(sum[:])
+
+	dest := filepath.Join(dir, "match")
+	require.NoError(t, agent.GetToFileWithChecksum(dest, server.URL, strings.ToUpper(expected)))
+
+	content, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	require.Equal(t, "hello sig-release!", string(content))
+
+	dest = filepath.Join(dir, "mismatch")
+	err = agent.GetToFileWithChecksum(dest, server.URL, strings.Repeat("0", 64))
+	require.ErrorIs(t, err, khttp.ErrChecksumMismatch)
+	require.NoFileExists(t, dest)
+
+	// Credentials are redacted from the error
+	const secret = "s3cr3t"
+
+	credentialed := strings.Replace(server.URL, "://", "://user:"+secret+"@", 1) +
+		"/file?token=" + secret
+
+	err = agent.GetToFileWithChecksum(dest, credentialed, strings.Repeat("0", 64))
+	require.ErrorIs(t, err, khttp.ErrChecksumMismatch)
+	require.NotContains(t, err.Error(), secret)
+	require.NoFileExists(t, dest)
+}
+
+func TestAgentProgressCallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/chunked" {
+				// Flushing before writing the body omits the Content-Length
+				w.(http.Flusher).Flush()
+			}
+
+			if _, err := io.WriteString(w, "hello sig-release!"); err != nil {
+				t.Fail()
+			}
+		}))
+	defer server.Close()
+
+	var written, total int64
+
+	agent := khttp.NewAgentWithOptions(khttp.WithProgressCallbackOpt(func(w, t int64) {
+		written, total = w, t
+	}))
+
+	var buf bytes.Buffer
+	require.NoError(t, agent.GetToWriter(&buf, server.URL))
+	require.EqualValues(t, 18, written)
+	require.EqualValues(t, 18, total)
+
+	_, err := agent.Get(server.URL + "/chunked")
+	require.NoError(t, err)
+	require.EqualValues(t, 18, written)
+	require.EqualValues(t, -1, total)
+
+	m := sync.Mutex{}
+	progress := map[int]int64{}
+
+	agent.WithGroupProgressCallback(func(i int, w, _ int64) {
+		m.Lock()
+		defer m.Unlock()
+
+		progress[i] = w
+	})
+
+	written = 0
+	_, errs := agent.GetGroup([]string{server.URL, server.URL + "/chunked", server.URL})
+	require.NoError(t, errors.Join(errs...))
+	require.Equal(t, map[int]int64{0: 18, 1: 18, 2: 18}, progress)
+	require.Zero(t, written)
+}
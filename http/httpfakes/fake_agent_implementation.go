@@ -18,6 +18,7 @@ limitations under the License.
 package httpfakes
 
 import (
+	"io"
 	httpa "net/http"
 	"sync"
 
@@ -25,6 +26,20 @@ import (
 )
 
 type FakeAgentImplementation struct {
+	SendDeleteRequestStub        func(*httpa.Client, string) (*httpa.Response, error)
+	sendDeleteRequestMutex       sync.RWMutex
+	sendDeleteRequestArgsForCall []struct {
+		arg1 *httpa.Client
+		arg2 string
+	}
+	sendDeleteRequestReturns struct {
+		result1 *httpa.Response
+		result2 error
+	}
+	sendDeleteRequestReturnsOnCall map[int]struct {
+		result1 *httpa.Response
+		result2 error
+	}
 	SendGetRequestStub        func(*httpa.Client, string) (*httpa.Response, error)
 	sendGetRequestMutex       sync.RWMutex
 	sendGetRequestArgsForCall []struct {
@@ -53,6 +68,23 @@ type FakeAgentImplementation struct {
 		result1 *httpa.Response
 		result2 error
 	}
+	SendPostReaderRequestStub        func(*httpa.Client, string, io.Reader, int64, string) (*httpa.Response, error)
+	sendPostReaderRequestMutex       sync.RWMutex
+	sendPostReaderRequestArgsForCall []struct {
+		arg1 *httpa.Client
+		arg2 string
+		arg3 io.Reader
+		arg4 int64
+		arg5 string
+	}
+	sendPostReaderRequestReturns struct {
+		result1 *httpa.Response
+		result2 error
+	}
+	sendPostReaderRequestReturnsOnCall map[int]struct {
+		result1 *httpa.Response
+		result2 error
+	}
 	SendPostRequestStub        func(*httpa.Client, string, []byte, string) (*httpa.Response, error)
 	sendPostRequestMutex       sync.RWMutex
 	sendPostRequestArgsForCall []struct {
@@ -69,10 +101,91 @@ type FakeAgentImplementation struct {
 		result1 *httpa.Response
 		result2 error
 	}
+	SendPutRequestStub        func(*httpa.Client, string, []byte, string) (*httpa.Response, error)
+	sendPutRequestMutex       sync.RWMutex
+	sendPutRequestArgsForCall []struct {
+		arg1 *httpa.Client
+		arg2 string
+		arg3 []byte
+		arg4 string
+	}
+	sendPutRequestReturns struct {
+		result1 *httpa.Response
+		result2 error
+	}
+	sendPutRequestReturnsOnCall map[int]struct {
+		result1 *httpa.Response
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeAgentImplementation) SendDeleteRequest(arg1 *httpa.Client, arg2 string) (*httpa.Response, error) {
+	fake.sendDeleteRequestMutex.Lock()
+	ret, specificReturn := fake.sendDeleteRequestReturnsOnCall[len(fake.sendDeleteRequestArgsForCall)]
+	fake.sendDeleteRequestArgsForCall = append(fake.sendDeleteRequestArgsForCall, struct {
+		arg1 *httpa.Client
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.SendDeleteRequestStub
+	fakeReturns := fake.sendDeleteRequestReturns
+	fake.recordInvocation("SendDeleteRequest", []interface{}{arg1, arg2})
+	fake.sendDeleteRequestMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAgentImplementation) SendDeleteRequestCallCount() int {
+	fake.sendDeleteRequestMutex.RLock()
+	defer fake.sendDeleteRequestMutex.RUnlock()
+	return len(fake.sendDeleteRequestArgsForCall)
+}
+
+func (fake *FakeAgentImplementation) SendDeleteRequestCalls(stub func(*httpa.Client, string) (*httpa.Response, error)) {
+	fake.sendDeleteRequestMutex.Lock()
+	defer fake.sendDeleteRequestMutex.Unlock()
+	fake.SendDeleteRequestStub = stub
+}
+
+func (fake *FakeAgentImplementation) SendDeleteRequestArgsForCall(i int) (*httpa.Client, string) {
+	fake.sendDeleteRequestMutex.RLock()
+	defer fake.sendDeleteRequestMutex.RUnlock()
+	argsForCall := fake.sendDeleteRequestArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAgentImplementation) SendDeleteRequestReturns(result1 *httpa.Response, result2 error) {
+	fake.sendDeleteRequestMutex.Lock()
+	defer fake.sendDeleteRequestMutex.Unlock()
+	fake.SendDeleteRequestStub = nil
+	fake.sendDeleteRequestReturns = struct {
+		result1 *httpa.Response
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgentImplementation) SendDeleteRequestReturnsOnCall(i int, result1 *httpa.Response, result2 error) {
+	fake.sendDeleteRequestMutex.Lock()
+	defer fake.sendDeleteRequestMutex.Unlock()
+	fake.SendDeleteRequestStub = nil
+	if fake.sendDeleteRequestReturnsOnCall == nil {
+		fake.sendDeleteRequestReturnsOnCall = make(map[int]struct {
+			result1 *httpa.Response
+			result2 error
+		})
+	}
+	fake.sendDeleteRequestReturnsOnCall[i] = struct {
+		result1 *httpa.Response
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAgentImplementation) SendGetRequest(arg1 *httpa.Client, arg2 string) (*httpa.Response, error) {
 	fake.sendGetRequestMutex.Lock()
 	ret, specificReturn := fake.sendGetRequestReturnsOnCall[len(fake.sendGetRequestArgsForCall)]
@@ -203,6 +316,74 @@ func (fake *FakeAgentImplementation) SendHeadRequestReturnsOnCall(i int, result1
 	}{result1, result2}
 }
 
+func (fake *FakeAgentImplementation) SendPostReaderRequest(arg1 *httpa.Client, arg2 string, arg3 io.Reader, arg4 int64, arg5 string) (*httpa.Response, error) {
+	fake.sendPostReaderRequestMutex.Lock()
+	ret, specificReturn := fake.sendPostReaderRequestReturnsOnCall[len(fake.sendPostReaderRequestArgsForCall)]
+	fake.sendPostReaderRequestArgsForCall = append(fake.sendPostReaderRequestArgsForCall, struct {
+		arg1 *httpa.Client
+		arg2 string
+		arg3 io.Reader
+		arg4 int64
+		arg5 string
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.SendPostReaderRequestStub
+	fakeReturns := fake.sendPostReaderRequestReturns
+	fake.recordInvocation("SendPostReaderRequest", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.sendPostReaderRequestMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAgentImplementation) SendPostReaderRequestCallCount() int {
+	fake.sendPostReaderRequestMutex.RLock()
+	defer fake.sendPostReaderRequestMutex.RUnlock()
+	return len(fake.sendPostReaderRequestArgsForCall)
+}
+
+func (fake *FakeAgentImplementation) SendPostReaderRequestCalls(stub func(*httpa.Client, string, io.Reader, int64, string) (*httpa.Response, error)) {
+	fake.sendPostReaderRequestMutex.Lock()
+	defer fake.sendPostReaderRequestMutex.Unlock()
+	fake.SendPostReaderRequestStub = stub
+}
+
+func (fake *FakeAgentImplementation) SendPostReaderRequestArgsForCall(i int) (*httpa.Client, string, io.Reader, int64, string) {
+	fake.sendPostReaderRequestMutex.RLock()
+	defer fake.sendPostReaderRequestMutex.RUnlock()
+	argsForCall := fake.sendPostReaderRequestArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeAgentImplementation) SendPostReaderRequestReturns(result1 *httpa.Response, result2 error) {
+	fake.sendPostReaderRequestMutex.Lock()
+	defer fake.sendPostReaderRequestMutex.Unlock()
+	fake.SendPostReaderRequestStub = nil
+	fake.sendPostReaderRequestReturns = struct {
+		result1 *httpa.Response
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgentImplementation) SendPostReaderRequestReturnsOnCall(i int, result1 *httpa.Response, result2 error) {
+	fake.sendPostReaderRequestMutex.Lock()
+	defer fake.sendPostReaderRequestMutex.Unlock()
+	fake.SendPostReaderRequestStub = nil
+	if fake.sendPostReaderRequestReturnsOnCall == nil {
+		fake.sendPostReaderRequestReturnsOnCall = make(map[int]struct {
+			result1 *httpa.Response
+			result2 error
+		})
+	}
+	fake.sendPostReaderRequestReturnsOnCall[i] = struct {
+		result1 *httpa.Response
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAgentImplementation) SendPostRequest(arg1 *httpa.Client, arg2 string, arg3 []byte, arg4 string) (*httpa.Response, error) {
 	var arg3Copy []byte
 	if arg3 != nil {
@@ -275,15 +456,93 @@ func (fake *FakeAgentImplementation) SendPostRequestReturnsOnCall(i int, result1
 	}{result1, result2}
 }
 
+func (fake *FakeAgentImplementation) SendPutRequest(arg1 *httpa.Client, arg2 string, arg3 []byte, arg4 string) (*httpa.Response, error) {
+	var arg3Copy []byte
+	if arg3 != nil {
+		arg3Copy = make([]byte, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.sendPutRequestMutex.Lock()
+	ret, specificReturn := fake.sendPutRequestReturnsOnCall[len(fake.sendPutRequestArgsForCall)]
+	fake.sendPutRequestArgsForCall = append(fake.sendPutRequestArgsForCall, struct {
+		arg1 *httpa.Client
+		arg2 string
+		arg3 []byte
+		arg4 string
+	}{arg1, arg2, arg3Copy, arg4})
+	stub := fake.SendPutRequestStub
+	fakeReturns := fake.sendPutRequestReturns
+	fake.recordInvocation("SendPutRequest", []interface{}{arg1, arg2, arg3Copy, arg4})
+	fake.sendPutRequestMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAgentImplementation) SendPutRequestCallCount() int {
+	fake.sendPutRequestMutex.RLock()
+	defer fake.sendPutRequestMutex.RUnlock()
+	return len(fake.sendPutRequestArgsForCall)
+}
+
+func (fake *FakeAgentImplementation) SendPutRequestCalls(stub func(*httpa.Client, string, []byte, string) (*httpa.Response, error)) {
+	fake.sendPutRequestMutex.Lock()
+	defer fake.sendPutRequestMutex.Unlock()
+	fake.SendPutRequestStub = stub
+}
+
+func (fake *FakeAgentImplementation) SendPutRequestArgsForCall(i int) (*httpa.Client, string, []byte, string) {
+	fake.sendPutRequestMutex.RLock()
+	defer fake.sendPutRequestMutex.RUnlock()
+	argsForCall := fake.sendPutRequestArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeAgentImplementation) SendPutRequestReturns(result1 *httpa.Response, result2 error) {
+	fake.sendPutRequestMutex.Lock()
+	defer fake.sendPutRequestMutex.Unlock()
+	fake.SendPutRequestStub = nil
+	fake.sendPutRequestReturns = struct {
+		result1 *httpa.Response
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgentImplementation) SendPutRequestReturnsOnCall(i int, result1 *httpa.Response, result2 error) {
+	fake.sendPutRequestMutex.Lock()
+	defer fake.sendPutRequestMutex.Unlock()
+	fake.SendPutRequestStub = nil
+	if fake.sendPutRequestReturnsOnCall == nil {
+		fake.sendPutRequestReturnsOnCall = make(map[int]struct {
+			result1 *httpa.Response
+			result2 error
+		})
+	}
+	fake.sendPutRequestReturnsOnCall[i] = struct {
+		result1 *httpa.Response
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAgentImplementation) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.sendDeleteRequestMutex.RLock()
+	defer fake.sendDeleteRequestMutex.RUnlock()
 	fake.sendGetRequestMutex.RLock()
 	defer fake.sendGetRequestMutex.RUnlock()
 	fake.sendHeadRequestMutex.RLock()
 	defer fake.sendHeadRequestMutex.RUnlock()
+	fake.sendPostReaderRequestMutex.RLock()
+	defer fake.sendPostReaderRequestMutex.RUnlock()
 	fake.sendPostRequestMutex.RLock()
 	defer fake.sendPostRequestMutex.RUnlock()
+	fake.sendPutRequestMutex.RLock()
+	defer fake.sendPutRequestMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
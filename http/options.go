@@ -0,0 +1,320 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// AgentOption is a functional option to configure an Agent created by
+// NewAgentWithOptions.
+type AgentOption func(*agentOptions)
+
+// NewAgentWithOptions returns a new agent with the default options modified
+// by the provided functional options. Contrary to NewAgent, every agent gets
+// its own set of options, which means that they do not share any state.
+func NewAgentWithOptions(opts ...AgentOption) *Agent {
+	options := newDefaultAgentOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return &Agent{
+		AgentImplementation: &defaultAgentImplementation{},
+		options:             options,
+	}
+}
+
+// WithTimeoutOpt sets the agent timeout.
+func WithTimeoutOpt(timeout time.Duration) AgentOption {
+	return func(o *agentOptions) {
+		o.Timeout = timeout
+	}
+}
+
+// WithRetriesOpt sets the number of times we'll attempt to fetch the URL.
+// See WithRetries for the exact semantics.
+func WithRetriesOpt(retries uint) AgentOption {
+	return func(o *agentOptions) {
+		o.Retries = retries
+	}
+}
+
+// WithNoRetriesOpt disables retries, which means that every request is
+// attempted exactly once.
+func WithNoRetriesOpt() AgentOption {
+	return func(o *agentOptions) {
+		o.Retries = 1
+	}
+}
+
+// WithWaitTimeOpt sets the initial wait time for request retry.
+func WithWaitTimeOpt(waitTime time.Duration) AgentOption {
+	return func(o *agentOptions) {
+		o.WaitTime = waitTime
+	}
+}
+
+// WithMaxWaitTimeOpt sets the maximum wait time for request retry.
+func WithMaxWaitTimeOpt(maxWaitTime time.Duration) AgentOption {
+	return func(o *agentOptions) {
+		o.MaxWaitTime = maxWaitTime
+	}
+}
+
+// WithFailOnHTTPErrorOpt determines if the agent fails on HTTP errors (HTTP
+// status not in 200s).
+func WithFailOnHTTPErrorOpt(flag bool) AgentOption {
+	return func(o *agentOptions) {
+		o.FailOnHTTPError = flag
+	}
+}
+
+// WithPostContentTypeOpt sets the content type to send when posting or
+// putting data.
+func WithPostContentTypeOpt(contentType string) AgentOption {
+	return func(o *agentOptions) {
+		o.PostContentType = contentType
+	}
+}
+
+// WithMaxParallelOpt controls how many requests we do when fetching groups.
+func WithMaxParallelOpt(workers uint) AgentOption {
+	return func(o *agentOptions) {
+		o.MaxParallel = workers
+	}
+}
+
+// WithLogAsCurlOpt enables logging every request as an equivalent curl
+// command on debug level.
+func WithLogAsCurlOpt(flag bool) AgentOption {
+	return func(o *agentOptions) {
+		o.LogAsCurl = flag
+	}
+}
+
+// WithResolverOpt sets a custom DNS resolver used to look up hostnames.
+func WithResolverOpt(resolver *net.Resolver) AgentOption {
+	return func(o *agentOptions) {
+		o.Resolver = resolver
+	}
+}
+
+// WithHostOverrideOpt statically resolves the provided host to addr, which
+// can be either a plain IP address or hostname, or one including a port.
+func WithHostOverrideOpt(host, addr string) AgentOption {
+	return func(o *agentOptions) {
+		if o.HostOverrides == nil {
+			o.HostOverrides = map[string]string{}
+		}
+
+		o.HostOverrides[host] = addr
+	}
+}
+
+// WithMaxConnsPerHostOpt limits the total number of connections per host.
+// Zero means no limit.
+func WithMaxConnsPerHostOpt(n int) AgentOption {
+	return func(o *agentOptions) {
+		o.MaxConnsPerHost = n
+	}
+}
+
+// WithMinTLSVersionOpt sets the minimum TLS version used for HTTPS requests.
+// Defaults to tls.VersionTLS12.
+func WithMinTLSVersionOpt(version uint16) AgentOption {
+	return func(o *agentOptions) {
+		o.MinTLSVersion = version
+	}
+}
+
+// WithMaxTLSVersionOpt sets the maximum TLS version used for HTTPS requests.
+// Zero means the highest version supported by Go.
+func WithMaxTLSVersionOpt(version uint16) AgentOption {
+	return func(o *agentOptions) {
+		o.MaxTLSVersion = version
+	}
+}
+
+// WithHeaderOpt adds a header which is sent along with every request.
+func WithHeaderOpt(key, value string) AgentOption {
+	return func(o *agentOptions) {
+		if o.Headers == nil {
+			o.Headers = http.Header{}
+		}
+
+		o.Headers.Add(key, value)
+	}
+}
+
+// WithHeadersOpt sets the provided headers, which are sent along with every
+// request. Existing values of the same keys get replaced.
+func WithHeadersOpt(headers map[string]string) AgentOption {
+	return func(o *agentOptions) {
+		if o.Headers == nil {
+			o.Headers = http.Header{}
+		}
+
+		for key, value := range headers {
+			o.Headers.Set(key, value)
+		}
+	}
+}
+
+// WithAcceptOpt sets the Accept header sent along with every request.
+func WithAcceptOpt(accept string) AgentOption {
+	return func(o *agentOptions) {
+		o.Accept = accept
+	}
+}
+
+// WithAcceptLanguageOpt sets the Accept-Language header sent along with every
+// request.
+func WithAcceptLanguageOpt(language string) AgentOption {
+	return func(o *agentOptions) {
+		o.AcceptLanguage = language
+	}
+}
+
+// WithBasicAuthOpt authenticates every request by using the provided user
+// name and password for basic authentication.
+func WithBasicAuthOpt(user, pass string) AgentOption {
+	return func(o *agentOptions) {
+		o.BasicAuthUser = user
+		o.BasicAuthPassword = pass
+		o.BearerToken = ""
+	}
+}
+
+// WithBearerTokenOpt authenticates every request by using the provided
+// bearer token.
+func WithBearerTokenOpt(token string) AgentOption {
+	return func(o *agentOptions) {
+		o.BearerToken = token
+		o.BasicAuthUser = ""
+		o.BasicAuthPassword = ""
+	}
+}
+
+// WithExpectedContentTypeOpt makes reading successful responses fail with
+// ErrUnexpectedContentType if their Content-Type does not start with the
+// provided one.
+func WithExpectedContentTypeOpt(contentType string) AgentOption {
+	return func(o *agentOptions) {
+		o.ExpectedContentType = contentType
+	}
+}
+
+// WithRetryableStatusCodesOpt sets the HTTP status codes on which GET, POST,
+// PUT, DELETE and HEAD requests get retried.
+func WithRetryableStatusCodesOpt(codes ...int) AgentOption {
+	return func(o *agentOptions) {
+		o.RetryableStatusCodes = statusCodeSet(codes)
+	}
+}
+
+// WithRespectRetryAfterOpt determines if the delay of the Retry-After response
+// header is used instead of the exponential backoff when retrying GET, POST,
+// PUT and DELETE requests.
+func WithRespectRetryAfterOpt(flag bool) AgentOption {
+	return func(o *agentOptions) {
+		o.RespectRetryAfter = flag
+	}
+}
+
+// WithJitterOpt determines if the exponential backoff delay between retries
+// gets randomized.
+func WithJitterOpt(flag bool) AgentOption {
+	return func(o *agentOptions) {
+		o.Jitter = flag
+	}
+}
+
+// WithClientOpt sets the http client used for all requests. The timeout
+// option is only applied if the client does not set its own timeout, while
+// all transport related options are ignored.
+func WithClientOpt(client *http.Client) AgentOption {
+	return func(o *agentOptions) {
+		o.Client = client
+	}
+}
+
+// WithTransportOpt sets the round tripper used for all requests. It has no
+// effect if a client is provided via WithClientOpt.
+func WithTransportOpt(transport http.RoundTripper) AgentOption {
+	return func(o *agentOptions) {
+		o.Transport = transport
+	}
+}
+
+// WithStreamThresholdOpt sets the size in bytes above which POST data is
+// streamed from a temporary file, zero disables streaming.
+func WithStreamThresholdOpt(n int64) AgentOption {
+	return func(o *agentOptions) {
+		o.StreamThreshold = n
+	}
+}
+
+// WithMaxBodySizeOpt limits the size of response bodies to n bytes, zero
+// means no limit.
+func WithMaxBodySizeOpt(n int64) AgentOption {
+	return func(o *agentOptions) {
+		o.MaxBodySize = n
+	}
+}
+
+// WithRateLimitOpt limits the number of requests per second of the agent,
+// zero means no limit.
+func WithRateLimitOpt(rps float64) AgentOption {
+	return func(o *agentOptions) {
+		o.RateLimit = rps
+	}
+}
+
+// WithURLRedactionOpt enables or disables the redaction of passwords and
+// sensitive query parameters from logged URLs.
+func WithURLRedactionOpt(enabled bool) AgentOption {
+	return func(o *agentOptions) {
+		o.URLRedaction = enabled
+	}
+}
+
+// WithAutoDecompressOpt enables or disables the decompression of gzip and
+// deflate encoded response bodies based on their Content-Encoding header.
+func WithAutoDecompressOpt(enabled bool) AgentOption {
+	return func(o *agentOptions) {
+		o.AutoDecompress = enabled
+	}
+}
+
+// WithProgressCallbackOpt sets a callback which reports the progress of
+// reading response bodies.
+func WithProgressCallbackOpt(callback func(written, total int64)) AgentOption {
+	return func(o *agentOptions) {
+		o.ProgressCallback = callback
+	}
+}
+
+// WithGroupProgressCallbackOpt sets a callback which reports the progress of
+// reading response bodies of group requests by their index.
+func WithGroupProgressCallbackOpt(callback func(index int, written, total int64)) AgentOption {
+	return func(o *agentOptions) {
+		o.GroupProgressCallback = callback
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAgentWithOptions(t *testing.T) {
+	agent := NewAgentWithOptions(
+		WithTimeoutOpt(time.Minute),
+		WithRetriesOpt(10),
+		WithWaitTimeOpt(time.Second),
+		WithMaxWaitTimeOpt(time.Hour),
+		WithFailOnHTTPErrorOpt(false),
+		WithPostContentTypeOpt("application/json"),
+		WithMaxParallelOpt(2),
+		WithLogAsCurlOpt(true),
+	)
+
+	require.Equal(t, &agentOptions{
+		FailOnHTTPError:   false,
+		Retries:           10,
+		Timeout:           time.Minute,
+		WaitTime:          time.Second,
+		MaxWaitTime:       time.Hour,
+		PostContentType:   "application/json",
+		MaxParallel:       2,
+		LogAsCurl:         true,
+		RespectRetryAfter: true,
+		Jitter:            true,
+		URLRedaction:      true,
+		MinTLSVersion:     tls.VersionTLS12,
+	}, agent.options)
+
+	// The options must not be shared between agents
+	require.NotSame(t, agent.options, NewAgentWithOptions().options)
+	require.Equal(t, newDefaultAgentOptions(), NewAgentWithOptions().options)
+}
+
+func TestNewAgentNotShared(t *testing.T) {
+	agent := NewAgent()
+	require.Equal(t, newDefaultAgentOptions(), agent.options)
+
+	agent.WithTimeout(time.Minute).
+		WithRetries(10).
+		WithFailOnHTTPError(false).
+		WithHeader("X-Test", "test")
+
+	// Configuring one agent must not change any other one
+	require.NotSame(t, agent.options, NewAgent().options)
+	require.Equal(t, newDefaultAgentOptions(), NewAgent().options)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/avast/retry-go/v4"
+	"golang.org/x/time/rate"
+)
+
+// getLimiter returns the rate limiter of the agent or nil if requests are not
+// rate limited. The limiter is built once and shared by all requests.
+func (a *Agent) getLimiter() *rate.Limiter {
+	a.limiterMu.Lock()
+	defer a.limiterMu.Unlock()
+
+	if a.options.RateLimit <= 0 {
+		return nil
+	}
+
+	if a.limiter == nil {
+		a.limiter = rate.NewLimiter(rate.Limit(a.options.RateLimit), 1)
+	}
+
+	return a.limiter
+}
+
+// resetLimiter discards the current rate limiter, which causes it to be
+// rebuilt with the latest options on next use.
+func (a *Agent) resetLimiter() {
+	a.limiterMu.Lock()
+	defer a.limiterMu.Unlock()
+
+	a.limiter = nil
+}
+
+// waitForRateLimit blocks until the rate limit allows the next request or the
+// context is done. The returned error is not retried.
+func (a *Agent) waitForRateLimit(ctx context.Context) error {
+	limiter := a.getLimiter()
+	if limiter == nil {
+		return nil
+	}
+
+	if err := limiter.Wait(ctx); err != nil {
+		return retry.Unrecoverable(fmt.Errorf("waiting for rate limit: %w", err))
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avast/retry-go/v4"
+)
+
+// retryAfterError is a retryable error of a response which contains a
+// Retry-After header.
+type retryAfterError struct {
+	err   error
+	delay time.Duration
+}
+
+// Error implements the error interface.
+func (e *retryAfterError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *retryAfterError) Unwrap() error {
+	return e.err
+}
+
+// withRetryAfter wraps the retryable error if the response contains a valid
+// Retry-After header.
+func withRetryAfter(resp *http.Response, err error) error {
+	if resp == nil {
+		return err
+	}
+
+	delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	if !ok {
+		return err
+	}
+
+	return &retryAfterError{err: err, delay: delay}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which can be
+// either a number of seconds or an HTTP date. Dates in the past result in a
+// zero delay.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	return max(date.Sub(now), 0), true
+}
+
+// retryDelay returns the delay before the next retry. The delay of the
+// Retry-After header is used if available and respected, while the
+// exponential backoff, optionally with jitter, is used otherwise. The retry
+// library caps the delay by the maximum wait time.
+func (a *Agent) retryDelay(n uint, err error, config *retry.Config) time.Duration {
+	retryAfterErr := &retryAfterError{}
+	if a.options.RespectRetryAfter && errors.As(err, &retryAfterErr) {
+		return retryAfterErr.delay
+	}
+
+	delay := retry.BackOffDelay(n, err, config)
+	if !a.options.Jitter {
+		return delay
+	}
+
+	if a.options.MaxWaitTime > 0 {
+		delay = min(delay, a.options.MaxWaitTime)
+	}
+
+	return a.jitter(delay)
+}
+
+// jitter returns a random duration between zero and ceiling.
+func (a *Agent) jitter(ceiling time.Duration) time.Duration {
+	if ceiling <= 0 {
+		return 0
+	}
+
+	randInt64N := a.randInt64N
+	if randInt64N == nil {
+		randInt64N = rand.Int64N //nolint:gosec // no cryptographic randomness required
+	}
+
+	return time.Duration(randInt64N(int64(ceiling) + 1))
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/avast/retry-go/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for value, expected := range map[string]time.Duration{
+		"2":                             2 * time.Second,
+		" 120 ":                         2 * time.Minute,
+		"0":                             0,
+		"Thu, 01 Jan 2026 12:00:30 GMT": 30 * time.Second,
+		"Thu, 01 Jan 2026 11:00:00 GMT": 0,
+	} {
+		res, ok := parseRetryAfter(value, now)
+		require.True(t, ok, value)
+		require.Equal(t, expected, res, value)
+	}
+
+	for _, value := range []string{"", "-1", "1.5", "tomorrow"} {
+		_, ok := parseRetryAfter(value, now)
+		require.False(t, ok, value)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"7"}}}
+	err := withRetryAfter(resp, errors.New("retry"))
+	require.EqualError(t, err, "retry")
+
+	agent := NewAgentWithOptions()
+	require.Equal(t, 7*time.Second, agent.retryDelay(1, err, &retry.Config{}))
+
+	agent.WithRespectRetryAfter(false)
+	require.NotEqual(t, 7*time.Second, agent.retryDelay(1, err, &retry.Config{}))
+}
+
+func TestRetryDelayJitter(t *testing.T) {
+	agent := NewAgentWithOptions(WithRespectRetryAfterOpt(false), WithMaxWaitTimeOpt(3*time.Second))
+	agent.randInt64N = func(n int64) int64 { return n / 2 }
+
+	config := &retry.Config{}
+	retry.Delay(time.Second)(config)
+
+	// Backoff of the second retry is 2s, which results in 1s jitter
+	require.Equal(t, time.Second, agent.retryDelay(1, errors.New("retry"), config))
+
+	// Backoff of the fourth retry is 8s, which gets capped to 3s before
+	// applying the jitter
+	require.Equal(t, 1500*time.Millisecond, agent.retryDelay(3, errors.New("retry"), config))
+
+	agent.WithJitter(false)
+	require.Equal(t, 2*time.Second, agent.retryDelay(1, errors.New("retry"), config))
+
+	require.Zero(t, agent.jitter(0))
+	require.Zero(t, agent.jitter(-time.Second))
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// needsTransport returns true if the options require a custom transport.
+// TLS 1.2 is already the minimum version of the default transport.
+func (ao *agentOptions) needsTransport() bool {
+	return ao.Resolver != nil || len(ao.HostOverrides) > 0 || ao.MaxConnsPerHost > 0 ||
+		(ao.MinTLSVersion != 0 && ao.MinTLSVersion != tls.VersionTLS12) || ao.MaxTLSVersion != 0
+}
+
+// getTransport returns the custom transport of the agent or nil if the
+// default transport should be used. The transport is built once and reused
+// for all clients to share their connection pool.
+func (a *Agent) getTransport() http.RoundTripper {
+	a.transportMu.Lock()
+	defer a.transportMu.Unlock()
+
+	if !a.options.needsTransport() {
+		return nil
+	}
+
+	if a.transport == nil {
+		a.transport = a.newTransport()
+	}
+
+	return a.transport
+}
+
+// resetTransport discards the current transport, which causes it to be
+// rebuilt with the latest options on next use.
+func (a *Agent) resetTransport() {
+	a.transportMu.Lock()
+	defer a.transportMu.Unlock()
+
+	if a.transport != nil {
+		a.transport.CloseIdleConnections()
+		a.transport = nil
+	}
+}
+
+// newTransport creates a new transport based on the agent options.
+func (a *Agent) newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxConnsPerHost = a.options.MaxConnsPerHost
+
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{} //nolint:gosec // the minimum version is set below
+	}
+
+	transport.TLSClientConfig.MinVersion = a.options.MinTLSVersion
+	transport.TLSClientConfig.MaxVersion = a.options.MaxTLSVersion
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Resolver:  a.options.Resolver,
+	}
+
+	overrides := maps.Clone(a.options.HostOverrides)
+
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, overrideAddr(overrides, addr))
+	}
+
+	return transport
+}
+
+// overrideAddr replaces the host of addr if an override exists. The original
+// port is kept if the override does not contain one.
+func overrideAddr(overrides map[string]string, addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	override, ok := overrides[host]
+	if !ok {
+		return addr
+	}
+
+	if _, _, err := net.SplitHostPort(override); err == nil {
+		return override
+	}
+
+	return net.JoinHostPort(override, port)
+}
+
+// headerRoundTripper adds headers to every request before passing it to the
+// next round tripper. Headers already set on the request, like the
+// Content-Type of POST requests, are kept.
+type headerRoundTripper struct {
+	headers http.Header
+	next    http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (h *headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := h.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	// Round trippers must not modify the original request.
+	req = req.Clone(req.Context())
+	for key, values := range h.headers {
+		if _, ok := req.Header[key]; ok {
+			continue
+		}
+
+		req.Header[key] = slices.Clone(values)
+	}
+
+	return next.RoundTrip(req)
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithHostOverride(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			_, err := io.WriteString(w, "hello")
+			if err != nil {
+				t.Fail()
+			}
+		}))
+	defer server.Close()
+
+	agent := NewAgentWithOptions(
+		WithRetriesOpt(1),
+		WithHostOverrideOpt("release.invalid", server.Listener.Addr().String()),
+	)
+
+	res, err := agent.Get("http://release.invalid/")
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(res))
+}
+
+func TestWithResolver(t *testing.T) {
+	agent := NewAgentWithOptions(WithRetriesOpt(1), WithWaitTimeOpt(0)).
+		WithResolver(&net.Resolver{
+			PreferGo: true,
+			Dial: func(context.Context, string, string) (net.Conn, error) {
+				return nil, errors.New("custom resolver")
+			},
+		})
+
+	_, err := agent.Get("http://release.invalid/")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "custom resolver")
+}
+
+func TestOverrideAddr(t *testing.T) {
+	overrides := map[string]string{
+		"example.com": "127.0.0.1",
+		"example.org": "127.0.0.1:8080",
+	}
+
+	for addr, expected := range map[string]string{
+		"example.com:443": "127.0.0.1:443",
+		"example.org:443": "127.0.0.1:8080",
+		"example.net:443": "example.net:443",
+		"invalid":         "invalid",
+	} {
+		require.Equal(t, expected, overrideAddr(overrides, addr))
+	}
+}
+
+func TestWithMaxConnsPerHost(t *testing.T) {
+	agent := NewAgentWithOptions()
+	require.Nil(t, agent.Client().Transport)
+
+	agent.WithMaxConnsPerHost(2)
+	transport, ok := agent.Client().Transport.(*http.Transport)
+	require.True(t, ok)
+	require.Equal(t, 2, transport.MaxConnsPerHost)
+	require.Same(t, transport, agent.Client().Transport)
+
+	agent.WithMaxConnsPerHost(3)
+	transport, ok = agent.Client().Transport.(*http.Transport)
+	require.True(t, ok)
+	require.Equal(t, 3, transport.MaxConnsPerHost)
+
+	agent = NewAgentWithOptions(WithMaxConnsPerHostOpt(4))
+	transport, ok = agent.Client().Transport.(*http.Transport)
+	require.True(t, ok)
+	require.Equal(t, 4, transport.MaxConnsPerHost)
+}
+
+func TestWithTLSVersion(t *testing.T) {
+	// TLS 1.2 is the minimum of the default transport
+	agent := NewAgentWithOptions()
+	require.Nil(t, agent.Client().Transport)
+
+	agent.WithMinTLSVersion(tls.VersionTLS13)
+	transport, ok := agent.Client().Transport.(*http.Transport)
+	require.True(t, ok)
+	require.Equal(t, uint16(tls.VersionTLS13), transport.TLSClientConfig.MinVersion)
+	require.Zero(t, transport.TLSClientConfig.MaxVersion)
+
+	agent = NewAgentWithOptions(WithMaxTLSVersionOpt(tls.VersionTLS12))
+	transport, ok = agent.Client().Transport.(*http.Transport)
+	require.True(t, ok)
+	require.Equal(t, uint16(tls.VersionTLS12), transport.TLSClientConfig.MinVersion)
+	require.Equal(t, uint16(tls.VersionTLS12), transport.TLSClientConfig.MaxVersion)
+
+	// Connecting to a server which only supports TLS 1.2
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	for _, tc := range []struct {
+		minVersion  uint16
+		shouldError bool
+	}{
+		{minVersion: tls.VersionTLS12},
+		{minVersion: tls.VersionTLS13, shouldError: true},
+	} {
+		agent := NewAgentWithOptions(
+			WithMinTLSVersionOpt(tc.minVersion),
+			WithMaxTLSVersionOpt(tls.VersionTLS13),
+			WithRetriesOpt(1),
+		)
+		transport, ok := agent.Client().Transport.(*http.Transport)
+		require.True(t, ok)
+		transport.TLSClientConfig.RootCAs = roots
+
+		_, err := agent.Get(server.URL)
+		if tc.shouldError {
+			require.Error(t, err)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	received := make(chan http.Header, 2)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(_ http.ResponseWriter, r *http.Request) {
+			received <- r.Header
+		}))
+	defer server.Close()
+
+	agent := NewAgentWithOptions(
+		WithRetriesOpt(1),
+		WithHeaderOpt("X-Custom", "value"),
+		WithHeaderOpt("Accept", "text/plain"),
+		WithHeaderOpt("Content-Type", "text/plain"),
+	).
+		WithAccept("application/json").
+		WithAcceptLanguage("de-DE").
+		WithContentType("application/yaml")
+
+	_, err := agent.Get(server.URL)
+	require.NoError(t, err)
+
+	headers := <-received
+	require.Equal(t, "value", headers.Get("X-Custom"))
+	require.Equal(t, []string{"application/json"}, headers.Values("Accept"))
+	require.Equal(t, "de-DE", headers.Get("Accept-Language"))
+
+	_, err = agent.Post(server.URL, []byte("data"))
+	require.NoError(t, err)
+
+	headers = <-received
+	require.Equal(t, "value", headers.Get("X-Custom"))
+	require.Equal(t, []string{"application/yaml"}, headers.Values("Content-Type"))
+}
+
+func TestHeadersGroup(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(_ http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			received = append(received, r.Method+" "+r.Header.Get("X-Custom")+" "+r.Header.Get("X-Other"))
+		}))
+	defer server.Close()
+
+	agent := NewAgentWithOptions(WithHeadersOpt(map[string]string{"X-Custom": "initial"})).
+		WithHeaders(map[string]string{"X-Custom": "value", "X-Other": "other"})
+
+	urls := []string{server.URL, server.URL}
+
+	_, errs := agent.GetGroup(urls)
+	require.NoError(t, errors.Join(errs...))
+
+	_, errs = agent.PostGroup(urls, [][]byte{nil, nil})
+	require.NoError(t, errors.Join(errs...))
+
+	_, err := agent.Head(server.URL)
+	require.NoError(t, err)
+
+	require.ElementsMatch(t, []string{
+		"GET value other", "GET value other",
+		"POST value other", "POST value other",
+		"HEAD value other",
+	}, received)
+}
+
+type countingRoundTripper struct {
+	calls int
+}
+
+func (c *countingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.calls++
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestWithClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			_, err := io.WriteString(w, r.Header.Get("X-Custom"))
+			if err != nil {
+				t.Fail()
+			}
+		}))
+	defer server.Close()
+
+	transport := &countingRoundTripper{}
+	client := &http.Client{Transport: transport, Timeout: time.Hour}
+
+	agent := NewAgentWithOptions(WithClientOpt(client), WithMaxConnsPerHostOpt(1))
+	require.Same(t, client, agent.Client())
+
+	res, err := agent.Get(server.URL)
+	require.NoError(t, err)
+	require.Empty(t, string(res))
+	require.Equal(t, 1, transport.calls)
+
+	// Headers require a copy of the client sharing the same transport
+	agent.WithHeader("X-Custom", "value")
+	require.NotSame(t, client, agent.Client())
+	require.Equal(t, time.Hour, agent.Client().Timeout)
+
+	res, err = agent.Get(server.URL)
+	require.NoError(t, err)
+	require.Equal(t, "value", string(res))
+	require.Equal(t, 2, transport.calls)
+
+	// The timeout of the agent applies if the client does not set one
+	agent = NewAgentWithOptions(WithTimeoutOpt(time.Minute)).WithClient(&http.Client{})
+	require.Equal(t, time.Minute, agent.Client().Timeout)
+}
+
+func TestWithTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	transport := &countingRoundTripper{}
+	agent := NewAgentWithOptions(WithMaxConnsPerHostOpt(1)).WithTransport(transport)
+	require.Same(t, transport, agent.Client().Transport)
+
+	_, err := agent.Get(server.URL)
+	require.NoError(t, err)
+	require.Equal(t, 1, transport.calls)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package redact provides functions to remove sensitive data, like tokens and
+// credentials, from data before logging it. It is shared between the util and
+// http packages.
+package redact
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Sanitized is the replacement for redacted data.
+const Sanitized = "__SANITIZED__"
+
+var (
+	regexpOauthToken = regexp.MustCompile(`[a-f0-9]{40}:x-oauth-basic`)
+	regexpGitToken   = regexp.MustCompile(`git:[a-f0-9]{35,40}@github\.com`)
+)
+
+// sensitiveQueryParams are the query parameter names whose values get
+// redacted, next to the ones containing any of sensitiveQueryParamParts.
+var sensitiveQueryParams = map[string]struct{}{
+	"key":     {},
+	"apikey":  {},
+	"api_key": {},
+	"sig":     {},
+}
+
+// sensitiveQueryParamParts are parts of query parameter names whose values
+// get redacted, like in "access_token" or "X-Amz-Signature".
+var sensitiveQueryParamParts = []string{
+	"token", "secret", "password", "passwd", "signature", "credential",
+}
+
+// Data removes data deemed sensitive or non public from a byte slice (ported
+// from the original bash anago).
+func Data(data []byte) []byte {
+	// Remove OAuth tokens
+	data = regexpOauthToken.ReplaceAllLiteral(data, []byte(Sanitized+":x-oauth-basic"))
+	// Remove GitHub tokens
+	data = regexpGitToken.ReplaceAllLiteral(data, []byte("//git:"+Sanitized+":@github.com"))
+
+	return data
+}
+
+// URL removes the password of the user info as well as the values of
+// sensitive query parameters, like "token" or "access_token", from the
+// provided URL. The order of the query parameters is retained. Unparseable
+// URLs are treated as arbitrary data, where only sensitive query parameters
+// get removed.
+func URL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		if base, query, found := strings.Cut(rawURL, "?"); found {
+			rawURL = base + "?" + redactQuery(query)
+		}
+
+		return string(Data([]byte(rawURL)))
+	}
+
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), Sanitized)
+	}
+
+	if u.RawQuery != "" {
+		u.RawQuery = redactQuery(u.RawQuery)
+	}
+
+	return string(Data([]byte(u.String())))
+}
+
+// redactQuery removes the values of sensitive parameters from the provided
+// raw query.
+func redactQuery(rawQuery string) string {
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		key, _, found := strings.Cut(param, "=")
+		if !found {
+			continue
+		}
+
+		if name, err := url.QueryUnescape(key); err == nil && isSensitiveQueryParam(name) {
+			params[i] = key + "=" + Sanitized
+		}
+	}
+
+	return strings.Join(params, "&")
+}
+
+// isSensitiveQueryParam returns true if the value of the query parameter
+// with the provided name should be redacted.
+func isSensitiveQueryParam(name string) bool {
+	name = strings.ToLower(name)
+
+	if _, ok := sensitiveQueryParams[name]; ok {
+		return true
+	}
+
+	for _, part := range sensitiveQueryParamParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+
+	return false
+}
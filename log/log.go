@@ -17,6 +17,7 @@ limitations under the License.
 package log
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -25,14 +26,41 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"sigs.k8s.io/release-utils/command"
+	"sigs.k8s.io/release-utils/util"
 )
 
 // SetupGlobalLogger uses to provided log level string and applies it globally.
 func SetupGlobalLogger(level string) error {
-	logrus.SetFormatter(&logrus.TextFormatter{
+	return setupGlobalLogger(level, &logrus.TextFormatter{
 		DisableTimestamp: true,
 		ForceColors:      false,
 	})
+}
+
+// SetupGlobalLoggerWithColor behaves like SetupGlobalLogger but explicitly
+// controls the colorization of the log output. If neither forceColor nor
+// disableColor is set, colors are only used if the current log output is a
+// terminal. Setting both flags results in an error.
+func SetupGlobalLoggerWithColor(level string, forceColor, disableColor bool) error {
+	if forceColor && disableColor {
+		return errors.New("unable to force and disable colors at the same time")
+	}
+
+	if !forceColor && !disableColor {
+		isTerminal := util.IsTerminal(logrus.StandardLogger().Out)
+		forceColor = isTerminal
+		disableColor = !isTerminal
+	}
+
+	return setupGlobalLogger(level, &logrus.TextFormatter{
+		DisableTimestamp: true,
+		ForceColors:      forceColor,
+		DisableColors:    disableColor,
+	})
+}
+
+func setupGlobalLogger(level string, formatter logrus.Formatter) error {
+	logrus.SetFormatter(formatter)
 
 	lvl, err := logrus.ParseLevel(level)
 	if err != nil {
@@ -65,6 +93,22 @@ func ToFile(fileName string) error {
 	return nil
 }
 
+// ToSyslog adds the local syslog daemon, or journald if it provides the syslog
+// socket, as destination to the global logger by using the provided tag. The
+// log levels are mapped to their corresponding syslog priorities, while the
+// existing output is being kept. Logging to syslog is not supported on Windows
+// and Plan 9, where an error is returned.
+func ToSyslog(tag string) error {
+	hook, err := newSyslogHook("", "", tag)
+	if err != nil {
+		return err
+	}
+
+	logrus.AddHook(hook)
+
+	return nil
+}
+
 // LevelNames returns a comma separated list of available levels.
 func LevelNames() string {
 	levels := []string{}
@@ -17,6 +17,7 @@ limitations under the License.
 package log_test
 
 import (
+	"bytes"
 	"os"
 	"testing"
 
@@ -41,3 +42,34 @@ func TestToFile(t *testing.T) {
 	require.Contains(t, string(content), "info")
 	require.Contains(t, string(content), "test")
 }
+
+func TestSetupGlobalLoggerWithColor(t *testing.T) {
+	defer logrus.SetOutput(os.Stderr)
+
+	logrus.SetOutput(&bytes.Buffer{})
+
+	for _, tc := range []struct {
+		forceColor, disableColor     bool
+		expectForced, expectDisabled bool
+		shouldError                  bool
+	}{
+		{forceColor: true, expectForced: true},
+		{disableColor: true, expectDisabled: true},
+		{expectDisabled: true}, // buffer is not a terminal
+		{forceColor: true, disableColor: true, shouldError: true},
+	} {
+		err := log.SetupGlobalLoggerWithColor("info", tc.forceColor, tc.disableColor)
+		if tc.shouldError {
+			require.Error(t, err)
+
+			continue
+		}
+
+		require.NoError(t, err)
+
+		formatter, ok := logrus.StandardLogger().Formatter.(*logrus.TextFormatter)
+		require.True(t, ok)
+		require.Equal(t, tc.expectForced, formatter.ForceColors)
+		require.Equal(t, tc.expectDisabled, formatter.DisableColors)
+	}
+}
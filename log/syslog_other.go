@@ -0,0 +1,30 @@
+//go:build windows || plan9
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
+)
+
+// newSyslogHook is not supported on Windows and Plan 9.
+func newSyslogHook(_, _, _ string) (logrus.Hook, error) {
+	return nil, errors.New("logging to syslog is not supported on this platform")
+}
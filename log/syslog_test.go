@@ -0,0 +1,68 @@
+//go:build !windows && !plan9
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyslogHook(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "syslog.sock")
+
+	conn, err := net.ListenPacket("unixgram", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	hook, err := newSyslogHook("unixgram", addr, "test")
+	require.NoError(t, err)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.AddHook(hook)
+
+	for _, tc := range []struct {
+		log              func(...any)
+		expectedPriority string
+	}{
+		{log: logger.Error, expectedPriority: "<11>"}, // user.err
+		{log: logger.Warn, expectedPriority: "<12>"},  // user.warning
+		{log: logger.Info, expectedPriority: "<14>"},  // user.info
+	} {
+		tc.log("message")
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+		buf := make([]byte, 1024)
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+
+		msg := string(buf[:n])
+		require.True(t, strings.HasPrefix(msg, tc.expectedPriority), msg)
+		require.Contains(t, msg, "test")
+		require.Contains(t, msg, "message")
+	}
+}
@@ -17,12 +17,15 @@ limitations under the License.
 package mage
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/blang/semver/v4"
@@ -144,3 +147,147 @@ func VerifyBoilerplate(version, binDir, boilerplateDir string, forceInstall bool
 
 	return nil
 }
+
+// boilerplateSkipDirs are the directories which are not verified by
+// VerifyBoilerplateGo.
+var boilerplateSkipDirs = map[string]struct{}{
+	".git":         {},
+	"_output":      {},
+	"node_modules": {},
+	"third_party":  {},
+	"vendor":       {},
+}
+
+// boilerplateGenerated is the template name used for generated Go files.
+const boilerplateGenerated = "generatego"
+
+var (
+	boilerplateYearRegex      = regexp.MustCompile(`Copyright \d{4}`)
+	boilerplateGoBuildRegex   = regexp.MustCompile(`(?m)^(//go:build.*|// \+build.*)\n+`)
+	boilerplateShebangRegex   = regexp.MustCompile(`^#!.*\n+`)
+	boilerplateGeneratedRegex = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`)
+)
+
+// VerifyBoilerplateGo runs copyright header checks for all files in the
+// current directory matching the provided extensions, like "go" or "sh". File
+// names like "Makefile" or "Dockerfile" can be used as extension, too. The
+// header of each file is compared against the boilerplate.<extension>.txt
+// template in the boilerplate directory, where the copyright year gets
+// normalized. Generated Go files are verified against the
+// boilerplate.generatego.txt template if it exists.
+//
+// Contrary to VerifyBoilerplate, it neither requires Python nor network
+// access.
+func VerifyBoilerplateGo(boilerplateDir string, extensions []string) error {
+	refs := map[string][]string{}
+	for _, ext := range extensions {
+		ext = strings.TrimPrefix(ext, ".")
+
+		ref, err := readBoilerplate(boilerplateDir, ext)
+		if err != nil {
+			return err
+		}
+
+		refs[ext] = ref
+	}
+
+	if _, ok := refs["go"]; ok {
+		ref, err := readBoilerplate(boilerplateDir, boilerplateGenerated)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		if ref != nil {
+			refs[boilerplateGenerated] = ref
+		}
+	}
+
+	offenders := []string{}
+	if err := filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if _, skip := boilerplateSkipDirs[d.Name()]; skip {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		ext := strings.TrimPrefix(filepath.Ext(path), ".")
+		if _, ok := refs[d.Name()]; ok {
+			ext = d.Name()
+		}
+
+		if _, ok := refs[ext]; !ok || ext == boilerplateGenerated {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+
+		if !hasBoilerplate(content, ext, refs) {
+			offenders = append(offenders, path)
+		}
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("walking source files: %w", err)
+	}
+
+	if len(offenders) > 0 {
+		return fmt.Errorf(
+			"%d files with missing or incorrect copyright header:\n%s",
+			len(offenders), strings.Join(offenders, "\n"),
+		)
+	}
+
+	return nil
+}
+
+// readBoilerplate reads the lines of the boilerplate template for the
+// extension.
+func readBoilerplate(boilerplateDir, ext string) ([]string, error) {
+	content, err := os.ReadFile(filepath.Join(boilerplateDir, "boilerplate."+ext+".txt"))
+	if err != nil {
+		return nil, fmt.Errorf("reading boilerplate template for %s: %w", ext, err)
+	}
+
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n"), nil
+}
+
+// hasBoilerplate returns true if the file content starts with the boilerplate
+// template of the extension.
+func hasBoilerplate(content []byte, ext string, refs map[string][]string) bool {
+	ref := refs[ext]
+
+	switch ext {
+	case "go":
+		if generatedRef, ok := refs[boilerplateGenerated]; ok &&
+			boilerplateGeneratedRegex.Match(content) {
+			ref = generatedRef
+		}
+
+		content = boilerplateGoBuildRegex.ReplaceAll(content, nil)
+	case "sh", "py":
+		content = boilerplateShebangRegex.ReplaceAll(content, nil)
+	}
+
+	lines := strings.Split(string(content), "\n")
+	if len(lines) < len(ref) {
+		return false
+	}
+
+	header := strings.Join(lines[:len(ref)], "\n")
+	if strings.Contains(header, "YEAR") {
+		return false
+	}
+
+	header = boilerplateYearRegex.ReplaceAllString(header, "Copyright YEAR")
+
+	return header == strings.Join(ref, "\n")
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mage
+
+import "testing"
+
+func TestHasBoilerplate(t *testing.T) {
+	refs := map[string][]string{
+		"go":         {"// Copyright YEAR Authors.", ""},
+		"sh":         {"# Copyright YEAR Authors.", ""},
+		"generatego": {"// Copyright Authors.", ""},
+	}
+
+	for _, tc := range []struct {
+		ext      string
+		content  string
+		expected bool
+	}{
+		{"go", "// Copyright 2021 Authors.\n\npackage x\n", true},
+		{"go", "//go:build linux\n\n// Copyright 2021 Authors.\n\npackage x\n", true},
+		{"go", "// Copyright YEAR Authors.\n\npackage x\n", false},
+		{"go", "// Copyright 2021 Authors.\npackage x\n", false},
+		{"go", "package x\n", false},
+		{"go", "// Copyright Authors.\n\n// Code generated by test. DO NOT EDIT.\npackage x\n", true},
+		{"sh", "#!/usr/bin/env bash\n\n# Copyright 2021 Authors.\n\nset -e\n", true},
+		{"sh", "#!/usr/bin/env bash\nset -e\n", false},
+	} {
+		if got := hasBoilerplate([]byte(tc.content), tc.ext, refs); got != tc.expected {
+			t.Errorf("hasBoilerplate(%q) = %v, expected %v", tc.content, got, tc.expected)
+		}
+	}
+}
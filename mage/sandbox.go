@@ -0,0 +1,116 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mage
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/release-utils/command"
+)
+
+// WithToolSandbox runs fn with a temporary GOPATH and GOBIN, which means that
+// tools installed by fn, for example via the Ensure* functions, do not modify
+// the GOPATH of the user. The bin directory of the sandbox gets passed to fn
+// and is prepended to PATH, so that the installed tools take precedence. The
+// module cache of the user is kept to avoid downloading all modules again.
+// The environment gets restored and the sandbox removed once fn returns.
+//
+// The environment is modified for the whole process, which means that
+// WithToolSandbox must not be used concurrently.
+func WithToolSandbox(fn func(binDir string) error) (err error) {
+	modCache, err := command.New("go", "env", "GOMODCACHE").RunSilentSuccessOutput()
+	if err != nil {
+		return fmt.Errorf("getting go module cache: %w", err)
+	}
+
+	sandbox, err := os.MkdirTemp("", "tool-sandbox-")
+	if err != nil {
+		return fmt.Errorf("creating tool sandbox: %w", err)
+	}
+
+	defer func() {
+		if removeErr := os.RemoveAll(sandbox); removeErr != nil {
+			log.Printf("Unable to remove tool sandbox %s: %v", sandbox, removeErr)
+		}
+	}()
+
+	binDir := filepath.Join(sandbox, "bin")
+	if err := os.MkdirAll(binDir, 0o755); err != nil {
+		return fmt.Errorf("creating tool sandbox bin directory: %w", err)
+	}
+
+	restore, err := setEnv(map[string]string{
+		"GOPATH":     sandbox,
+		"GOBIN":      binDir,
+		"GOMODCACHE": modCache.OutputTrimNL(),
+		"PATH":       binDir + string(os.PathListSeparator) + os.Getenv("PATH"),
+	})
+
+	defer func() {
+		if restoreErr := restore(); restoreErr != nil && err == nil {
+			err = restoreErr
+		}
+	}()
+
+	if err != nil {
+		return err
+	}
+
+	return fn(binDir)
+}
+
+// setEnv sets the provided environment variables and returns a function to
+// restore their previous values. The restore function has to be called even
+// if an error is returned.
+func setEnv(env map[string]string) (restore func() error, err error) {
+	previous := map[string]*string{}
+
+	restore = func() error {
+		for key, value := range previous {
+			if value == nil {
+				if err := os.Unsetenv(key); err != nil {
+					return fmt.Errorf("unsetting %s: %w", key, err)
+				}
+
+				continue
+			}
+
+			if err := os.Setenv(key, *value); err != nil {
+				return fmt.Errorf("restoring %s: %w", key, err)
+			}
+		}
+
+		return nil
+	}
+
+	for key, value := range env {
+		if old, ok := os.LookupEnv(key); ok {
+			previous[key] = &old
+		} else {
+			previous[key] = nil
+		}
+
+		if err := os.Setenv(key, value); err != nil {
+			return restore, fmt.Errorf("setting %s: %w", key, err)
+		}
+	}
+
+	return restore, nil
+}
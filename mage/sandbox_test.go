@@ -0,0 +1,51 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithToolSandbox(t *testing.T) {
+	t.Setenv("GOBIN", "/original/bin")
+	t.Setenv("GOPATH", "/original")
+
+	var sandboxBinDir string
+
+	err := WithToolSandbox(func(binDir string) error {
+		sandboxBinDir = binDir
+
+		require.DirExists(t, binDir)
+		require.Equal(t, binDir, os.Getenv("GOBIN"))
+		require.Equal(t, filepath.Dir(binDir), os.Getenv("GOPATH"))
+		require.True(t, strings.HasPrefix(os.Getenv("PATH"), binDir))
+
+		return errors.New("test")
+	})
+	require.EqualError(t, err, "test")
+
+	require.NoDirExists(t, sandboxBinDir)
+	require.Equal(t, "/original/bin", os.Getenv("GOBIN"))
+	require.Equal(t, "/original", os.Getenv("GOPATH"))
+	require.False(t, strings.HasPrefix(os.Getenv("PATH"), sandboxBinDir))
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tar
+
+import (
+	"archive/tar"
+	"regexp"
+)
+
+// DefaultBufferSize is the default size of the buffer used to copy file
+// contents from and to tarballs.
+const DefaultBufferSize = 32 * 1024
+
+// Compression is the compression format of a tarball.
+type Compression int
+
+const (
+	// CompressionGzip compresses the tarball by using gzip, which is the
+	// default.
+	CompressionGzip Compression = iota
+
+	// CompressionNone creates a plain, uncompressed tarball.
+	CompressionNone
+
+	// CompressionZstd compresses the tarball by using zstd, which is usually
+	// much faster than gzip at a similar ratio.
+	CompressionZstd
+)
+
+// Option is a functional option for `CompressWithOptions` and
+// `ExtractWithOptions`.
+type Option func(*options)
+
+// options are the internal options used for compressing and extracting.
+type options struct {
+	preserveRootDirStructure bool
+	dereferenceSymlinks      bool
+	excludes                 []*regexp.Regexp
+	bufferSize               int
+	maxSize                  int64
+	maxFileSize              int64
+	stripComponents          int
+	compression              Compression
+	compressionLevel         int
+	preserveTimes            bool
+	preserveOwners           bool
+	onEntry                  func(*tar.Header)
+	selected                 map[string]bool // extracted member names if set, mapped to whether they were found
+}
+
+// newOptions returns the default options modified by opts.
+func newOptions(opts ...Option) *options {
+	o := &options{
+		preserveRootDirStructure: true,
+		bufferSize:               DefaultBufferSize,
+		preserveTimes:            true,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithExcludes excludes all files matching any of the regular expression
+// patterns from compression.
+func WithExcludes(excludes ...*regexp.Regexp) Option {
+	return func(o *options) {
+		o.excludes = append(o.excludes, excludes...)
+	}
+}
+
+// WithPreserveRootDirStructure determines if the path between the tarball
+// and the contents directory should be preserved inside the archive when
+// compressing. Defaults to true.
+func WithPreserveRootDirStructure(preserve bool) Option {
+	return func(o *options) {
+		o.preserveRootDirStructure = preserve
+	}
+}
+
+// WithDereferenceSymlinks determines if symlinks should be followed when
+// compressing instead of storing them as links. Defaults to false.
+func WithDereferenceSymlinks(dereference bool) Option {
+	return func(o *options) {
+		o.dereferenceSymlinks = dereference
+	}
+}
+
+// WithBufferSize sets the size of the buffer used to copy file contents when
+// compressing and extracting. Defaults to `DefaultBufferSize`, non-positive
+// values are ignored.
+func WithBufferSize(size int) Option {
+	return func(o *options) {
+		if size > 0 {
+			o.bufferSize = size
+		}
+	}
+}
+
+// WithMaxSize limits the total size of the file contents when extracting to
+// maxBytes, which protects against decompression bombs. Extracting fails with
+// ErrMaxSizeExceeded if the limit is exceeded. Defaults to zero, which means
+// no limit.
+func WithMaxSize(maxBytes int64) Option {
+	return func(o *options) {
+		o.maxSize = maxBytes
+	}
+}
+
+// WithMaxFileSize limits the size of every single file when extracting to
+// maxBytes. Extracting fails with ErrMaxFileSizeExceeded if the limit is
+// exceeded, which is already checked before writing a file if its size in the
+// tarball exceeds the limit. Defaults to zero, which means no limit. Use it
+// together with WithMaxSize to protect against decompression bombs. It also
+// limits the file read into memory by ReadFileFromGzippedTar.
+func WithMaxFileSize(maxBytes int64) Option {
+	return func(o *options) {
+		o.maxFileSize = maxBytes
+	}
+}
+
+// WithStripComponents strips the provided number of leading path components
+// from the file names when extracting, like `tar --strip-components` does.
+// This is useful to get rid of a wrapping top-level directory. Files with
+// fewer path components are skipped entirely. Defaults to zero, while
+// negative values are ignored.
+func WithStripComponents(n int) Option {
+	return func(o *options) {
+		o.stripComponents = max(n, 0)
+	}
+}
+
+// WithCompression sets the compression format used when compressing.
+// Defaults to CompressionGzip. Extracting does not require this option,
+// because all supported formats are detected automatically.
+func WithCompression(compression Compression) Option {
+	return func(o *options) {
+		o.compression = compression
+	}
+}
+
+// WithCompressionLevel sets the level of the compression format used when
+// compressing, which trades speed for size. The levels range from 1 (fastest)
+// to 9 (best compression) for gzip and from 1 to 22 for zstd, like the
+// respective command line tools use them. Defaults to zero, which selects the
+// default level of the format.
+func WithCompressionLevel(level int) Option {
+	return func(o *options) {
+		o.compressionLevel = level
+	}
+}
+
+// WithPreserveTimes determines if the modification times of the archived
+// files and directories should be restored when extracting. Symlinks keep
+// the time of extraction. Defaults to true.
+func WithPreserveTimes(preserve bool) Option {
+	return func(o *options) {
+		o.preserveTimes = preserve
+	}
+}
+
+// WithPreserveOwners determines if the user and group IDs of the archived
+// files, directories and symlinks should be restored when extracting. This
+// usually requires to run as root and is not supported on Windows, where
+// extracting fails if enabled. Defaults to false.
+func WithPreserveOwners(preserve bool) Option {
+	return func(o *options) {
+		o.preserveOwners = preserve
+	}
+}
+
+// WithOnEntry sets a callback which gets called for every entry processed, for
+// example to report the progress of large tarballs. When compressing, it is
+// called for every entry written to the archive, which excludes skipped files.
+// When extracting, it is called for every entry right before it gets written.
+// The header must not be modified.
+func WithOnEntry(fn func(header *tar.Header)) Option {
+	return func(o *options) {
+		o.onEntry = fn
+	}
+}
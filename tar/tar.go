@@ -18,16 +18,23 @@ package tar
 
 import (
 	"archive/tar"
+	"bufio"
+	"bytes"
 	"compress/gzip"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/release-utils/hash"
+	"sigs.k8s.io/release-utils/util"
 )
 
 // Compress the provided  `tarContentsPath` into the `tarFilePath` while
@@ -35,30 +42,96 @@ import (
 // preserve path between `tarFilePath` and `tarContentsPath` directories inside
 // the archive (see `CompressWithoutPreservingPath` as an alternative).
 func Compress(tarFilePath, tarContentsPath string, excludes ...*regexp.Regexp) error {
-	return compress(true, tarFilePath, tarContentsPath, excludes...)
+	return CompressWithOptions(tarFilePath, tarContentsPath, WithExcludes(excludes...))
 }
 
 // Compress the provided  `tarContentsPath` into the `tarFilePath` while
 // excluding the `exclude` regular expression patterns. This function will
 // not preserve path leading to the `tarContentsPath` directory in the archive.
 func CompressWithoutPreservingPath(tarFilePath, tarContentsPath string, excludes ...*regexp.Regexp) error {
-	return compress(false, tarFilePath, tarContentsPath, excludes...)
+	return CompressWithOptions(
+		tarFilePath, tarContentsPath,
+		WithExcludes(excludes...),
+		WithPreserveRootDirStructure(false),
+	)
+}
+
+// CompressDereferenceSymlinks behaves like `Compress` but follows symlinks
+// instead of storing them as links. Symlinks to files are stored as regular
+// files containing the contents of the target, while symlinks to directories
+// get the contents of the target directory stored below the link path. The
+// function errors on symlink cycles.
+func CompressDereferenceSymlinks(tarFilePath, tarContentsPath string, excludes ...*regexp.Regexp) error {
+	return CompressWithOptions(
+		tarFilePath, tarContentsPath,
+		WithExcludes(excludes...),
+		WithDereferenceSymlinks(true),
+	)
+}
+
+// CompressWithOptions compresses the provided `tarContentsPath` into the
+// `tarFilePath` by using the provided options. Without any option, it
+// behaves like `Compress` without excludes.
+func CompressWithOptions(tarFilePath, tarContentsPath string, opts ...Option) error {
+	return compress(newOptions(opts...), tarFilePath, tarContentsPath)
 }
 
-func compress(preserveRootDirStructure bool, tarFilePath, tarContentsPath string, excludes ...*regexp.Regexp) error {
+func compress(opts *options, tarFilePath, tarContentsPath string) error {
+	if opts.compression != CompressionGzip &&
+		opts.compression != CompressionNone &&
+		opts.compression != CompressionZstd {
+		return fmt.Errorf("unsupported compression: %d", opts.compression)
+	}
+
 	tarFile, err := os.Create(tarFilePath)
 	if err != nil {
 		return fmt.Errorf("create tar file %q: %w", tarFilePath, err)
 	}
 	defer tarFile.Close()
 
-	gzipWriter := gzip.NewWriter(tarFile)
-	defer gzipWriter.Close()
+	var w io.Writer = tarFile
+
+	switch opts.compression {
+	case CompressionGzip:
+		level := gzip.DefaultCompression
+		if opts.compressionLevel != 0 {
+			level = opts.compressionLevel
+		}
+
+		gzipWriter, err := gzip.NewWriterLevel(tarFile, level)
+		if err != nil {
+			return fmt.Errorf("create gzip writer: %w", err)
+		}
+		defer gzipWriter.Close()
 
-	tarWriter := tar.NewWriter(gzipWriter)
+		w = gzipWriter
+	case CompressionZstd:
+		level := zstd.SpeedDefault
+		if opts.compressionLevel != 0 {
+			level = zstd.EncoderLevelFromZstd(opts.compressionLevel)
+		}
+
+		zstdWriter, err := zstd.NewWriter(tarFile, zstd.WithEncoderLevel(level))
+		if err != nil {
+			return fmt.Errorf("create zstd writer: %w", err)
+		}
+		defer zstdWriter.Close()
+
+		w = zstdWriter
+	case CompressionNone:
+	}
+
+	tarWriter := tar.NewWriter(w)
 	defer tarWriter.Close()
 
-	if err := filepath.Walk(tarContentsPath, func(filePath string, fileInfo os.FileInfo, err error) error {
+	buf := make([]byte, opts.bufferSize)
+
+	walk := filepath.Walk
+	if opts.dereferenceSymlinks {
+		walk = walkDereferenced
+	}
+
+	if err := walk(tarContentsPath, func(filePath string, fileInfo os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -77,13 +150,13 @@ func compress(preserveRootDirStructure bool, tarFilePath, tarContentsPath string
 			return fmt.Errorf("create file info header for %q: %w", filePath, err)
 		}
 
-		if fileInfo.IsDir() || filePath == tarFilePath {
+		if filePath == tarFilePath {
 			logrus.Tracef("Skipping: %s", filePath)
 
 			return nil
 		}
 
-		for _, re := range excludes {
+		for _, re := range opts.excludes {
 			if re != nil && re.MatchString(filePath) {
 				logrus.Tracef("Excluding: %s", filePath)
 
@@ -102,7 +175,7 @@ func compress(preserveRootDirStructure bool, tarFilePath, tarContentsPath string
 		// In such case we can disable `preserveRootDirStructure` flag which
 		// will make paths inside the archive relative to `tarContentsPath`.
 		dropPath := filepath.Dir(tarFilePath)
-		if !preserveRootDirStructure {
+		if !opts.preserveRootDirStructure {
 			dropPath = tarContentsPath
 		}
 		header.Name = strings.TrimLeft(
@@ -111,17 +184,31 @@ func compress(preserveRootDirStructure bool, tarFilePath, tarContentsPath string
 		)
 		header.Linkname = filepath.ToSlash(header.Linkname)
 
+		// Directories are stored as separate entries to keep empty ones,
+		// except of the root directory if it is not preserved.
+		if fileInfo.IsDir() {
+			if header.Name == "" {
+				return nil
+			}
+
+			header.Name += "/"
+		}
+
 		if err := tarWriter.WriteHeader(header); err != nil {
 			return fmt.Errorf("writing tar header: %w", err)
 		}
 
-		if !isLink {
+		if opts.onEntry != nil {
+			opts.onEntry(header)
+		}
+
+		if !isLink && !fileInfo.IsDir() {
 			file, err := os.Open(filePath)
 			if err != nil {
 				return fmt.Errorf("open file %q: %w", filePath, err)
 			}
 
-			if _, err := io.Copy(tarWriter, file); err != nil {
+			if _, err := copyBuffer(tarWriter, file, buf); err != nil {
 				return fmt.Errorf("writing file to tar writer: %w", err)
 			}
 
@@ -136,15 +223,141 @@ func compress(preserveRootDirStructure bool, tarFilePath, tarContentsPath string
 	return nil
 }
 
+// walkDereferenced behaves like `filepath.Walk` but follows symlinks. The
+// function errors if a symlink cycle is detected.
+func walkDereferenced(root string, fn filepath.WalkFunc) error {
+	return walkDereferencedRecursive(root, map[string]struct{}{}, fn)
+}
+
+func walkDereferencedRecursive(
+	path string, ancestors map[string]struct{}, fn filepath.WalkFunc,
+) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fn(path, nil, err)
+	}
+
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fn(path, info, fmt.Errorf("evaluate symlinks of %s: %w", path, err))
+	}
+
+	if _, ok := ancestors[realPath]; ok {
+		return fmt.Errorf("symlink cycle detected: %s points to %s", path, realPath)
+	}
+
+	ancestors[realPath] = struct{}{}
+	defer delete(ancestors, realPath)
+
+	if err := fn(path, info, nil); err != nil {
+		if errors.Is(err, filepath.SkipDir) {
+			return nil
+		}
+
+		return err
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return fn(path, info, err)
+	}
+
+	for _, entry := range entries {
+		if err := walkDereferencedRecursive(
+			filepath.Join(path, entry.Name()), ancestors, fn,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Extract can be used to extract the provided `tarFilePath` into the
 // `destinationPath`.
 func Extract(tarFilePath, destinationPath string) error {
-	return iterateTarball(
-		tarFilePath,
+	return ExtractWithOptions(tarFilePath, destinationPath)
+}
+
+// ExtractWithOptions extracts the provided `tarFilePath` into the
+// `destinationPath` by using the provided options. Gzipped and plain tarballs
+// are supported.
+//
+// The options are functional options, like WithStripComponents or
+// WithOnEntry, instead of an ExtractOptions struct. They are shared with
+// CompressWithOptions and ExtractFromReader, which allows adding further
+// options without breaking existing callers.
+func ExtractWithOptions(tarFilePath, destinationPath string, opts ...Option) error {
+	file, err := os.Open(tarFilePath)
+	if err != nil {
+		return fmt.Errorf("opening tar file %q: %w", tarFilePath, err)
+	}
+	defer file.Close()
+
+	if err := ExtractFromReader(file, destinationPath, opts...); err != nil {
+		return fmt.Errorf("extracting tar file %q: %w", tarFilePath, err)
+	}
+
+	return nil
+}
+
+// ExtractFromReader extracts the gzipped or plain tarball read from `r` into
+// the `destinationPath` by using the provided options. The tarball is
+// processed while reading, which allows to extract it without storing it
+// first, for example when downloading it.
+func ExtractFromReader(r io.Reader, destinationPath string, opts ...Option) error {
+	o := newOptions(opts...)
+	buf := make([]byte, o.bufferSize)
+
+	// The remaining number of bytes allowed to be extracted if limited
+	remaining := o.maxSize
+
+	// The number of selected members which have not been found yet
+	pending := len(o.selected)
+
+	// The extracted directories, whose mode and metadata get restored at the
+	// end because adding their contents changes their modification time and
+	// may not be permitted by their mode
+	dirs := map[string]*tar.Header{}
+
+	if err := iterateTarballReader(
+		r,
 		func(reader *tar.Reader, header *tar.Header) (stop bool, err error) {
+			if o.selected != nil {
+				member := strings.TrimSuffix(header.Name, "/")
+
+				found, ok := o.selected[member]
+				if !ok {
+					return false, nil
+				}
+
+				if !found {
+					o.selected[member] = true
+					pending--
+				}
+
+				// Skip the rest of the tarball after the last selected member
+				defer func() { stop = err == nil && pending == 0 }()
+			}
+
+			name, ok := stripComponents(header.Name, o.stripComponents)
+			if !ok {
+				logrus.Tracef("Skipping %s with less path components than stripped", header.Name)
+
+				return false, nil
+			}
+
+			if o.onEntry != nil {
+				o.onEntry(header)
+			}
+
 			switch header.Typeflag {
 			case tar.TypeDir:
-				targetDir, err := SanitizeArchivePath(destinationPath, header.Name)
+				targetDir, err := SanitizeArchivePath(destinationPath, name)
 				if err != nil {
 					return false, fmt.Errorf("SanitizeArchivePath: %w", err)
 				}
@@ -154,8 +367,10 @@ func Extract(tarFilePath, destinationPath string) error {
 				if err := os.MkdirAll(targetDir, os.FileMode(0o755)); err != nil {
 					return false, fmt.Errorf("create target directory: %w", err)
 				}
+
+				dirs[targetDir] = header
 			case tar.TypeSymlink:
-				targetFile, err := SanitizeArchivePath(destinationPath, header.Name)
+				targetFile, err := SanitizeArchivePath(destinationPath, name)
 				if err != nil {
 					return false, fmt.Errorf("SanitizeArchivePath: %w", err)
 				}
@@ -173,14 +388,33 @@ func Extract(tarFilePath, destinationPath string) error {
 				if err := os.Symlink(header.Linkname, targetFile); err != nil {
 					return false, fmt.Errorf("create symlink: %w", err)
 				}
+
+				if err := o.restoreMetadata(targetFile, header); err != nil {
+					return false, err
+				}
 				// tar.TypeRegA has been deprecated since Go 1.11
 				// should we just remove?
 			case tar.TypeReg:
-				targetFile, err := SanitizeArchivePath(destinationPath, header.Name)
+				targetFile, err := SanitizeArchivePath(destinationPath, name)
 				if err != nil {
 					return false, fmt.Errorf("SanitizeArchivePath: %w", err)
 				}
 
+				// Fail early if the declared size already exceeds the limits
+				if o.maxFileSize > 0 && header.Size > o.maxFileSize {
+					return false, fmt.Errorf(
+						"%w: %s declares %d bytes, limit is %d bytes",
+						ErrMaxFileSizeExceeded, header.Name, header.Size, o.maxFileSize,
+					)
+				}
+
+				if o.maxSize > 0 && header.Size > remaining {
+					return false, fmt.Errorf(
+						"%w: %s declares %d bytes, exceeding the total of %d bytes",
+						ErrMaxSizeExceeded, header.Name, header.Size, o.maxSize,
+					)
+				}
+
 				logrus.Tracef("Creating file %s", targetFile)
 
 				if err := os.MkdirAll(
@@ -198,12 +432,42 @@ func Extract(tarFilePath, destinationPath string) error {
 					return false, fmt.Errorf("chmod target file: %w", err)
 				}
 
-				if _, err := io.Copy(outFile, reader); err != nil {
-					return false, fmt.Errorf("copy file contents %s: %w", targetFile, err)
+				// Read one byte more than allowed to detect exceeding content.
+				var src io.Reader = reader
+				if o.maxSize > 0 {
+					src = io.LimitReader(src, remaining+1)
 				}
 
+				if o.maxFileSize > 0 {
+					src = io.LimitReader(src, o.maxFileSize+1)
+				}
+
+				written, err := copyBuffer(outFile, src, buf)
 				outFile.Close()
 
+				if err != nil {
+					return false, fmt.Errorf("copy file contents %s: %w", targetFile, err)
+				}
+
+				if o.maxFileSize > 0 && written > o.maxFileSize {
+					return false, fmt.Errorf(
+						"%w: %s exceeds %d bytes", ErrMaxFileSizeExceeded, header.Name, o.maxFileSize,
+					)
+				}
+
+				if o.maxSize > 0 {
+					remaining -= written
+					if remaining < 0 {
+						return false, fmt.Errorf(
+							"%w: exceeds %d bytes", ErrMaxSizeExceeded, o.maxSize,
+						)
+					}
+				}
+
+				if err := o.restoreMetadata(targetFile, header); err != nil {
+					return false, err
+				}
+
 			default:
 				logrus.Warnf(
 					"File %s has unknown type %s",
@@ -213,29 +477,180 @@ func Extract(tarFilePath, destinationPath string) error {
 
 			return false, nil
 		},
-	)
+	); err != nil {
+		return err
+	}
+
+	for dir, header := range dirs {
+		if err := os.Chmod(dir, header.FileInfo().Mode().Perm()); err != nil {
+			return fmt.Errorf("chmod target directory: %w", err)
+		}
+
+		if err := o.restoreMetadata(dir, header); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreMetadata restores the ownership and modification time of the
+// extracted path from the tar header, depending on the options.
+func (o *options) restoreMetadata(path string, header *tar.Header) error {
+	if o.preserveOwners {
+		if err := os.Lchown(path, header.Uid, header.Gid); err != nil {
+			return fmt.Errorf("change owner of %s: %w", path, err)
+		}
+
+		// Changing the owner may clear the setuid and setgid bits
+		if header.Typeflag == tar.TypeReg {
+			if err := os.Chmod(path, header.FileInfo().Mode()); err != nil {
+				return fmt.Errorf("chmod %s: %w", path, err)
+			}
+		}
+	}
+
+	// Changing the times of symlinks is not portable, because os.Chtimes
+	// follows them
+	if o.preserveTimes && header.Typeflag != tar.TypeSymlink && !header.ModTime.IsZero() {
+		accessTime := header.AccessTime
+		if accessTime.IsZero() {
+			accessTime = header.ModTime
+		}
+
+		if err := os.Chtimes(path, accessTime, header.ModTime); err != nil {
+			return fmt.Errorf("change times of %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// ExtractFiles extracts only the members of the provided `tarFilePath` whose
+// names are contained in `names` into the `destinationPath`. Directory members
+// are matched with or without trailing slash, but their contents have to be
+// selected separately. Reading the tarball stops as soon as all members have
+// been extracted. Returns `ErrMemberNotFound` if any of the names does not
+// exist in the tarball.
+func ExtractFiles(tarFilePath, destinationPath string, names []string) error {
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		selected[strings.TrimSuffix(name, "/")] = false
+	}
+
+	if err := ExtractWithOptions(tarFilePath, destinationPath, func(o *options) {
+		o.selected = selected
+	}); err != nil {
+		return err
+	}
+
+	missing := []string{}
+
+	for _, name := range names {
+		if !selected[strings.TrimSuffix(name, "/")] {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMemberNotFound, strings.Join(missing, ", "))
+	}
+
+	return nil
 }
 
-// Sanitize archive file pathing from "G305: Zip Slip vulnerability"
+// stripComponents removes the first n path components from the provided
+// archive path. It returns false if the path does not have more than n
+// components.
+func stripComponents(path string, n int) (string, bool) {
+	if n == 0 {
+		return path, true
+	}
+
+	components := strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+	if len(components) <= n {
+		return "", false
+	}
+
+	return strings.Join(components[n:], "/"), true
+}
+
+// copyBuffer copies from src to dst by using the provided buffer. Contrary to
+// `io.CopyBuffer`, the buffer is always used because the optional
+// `io.ReaderFrom` and `io.WriterTo` implementations are hidden.
+func copyBuffer(dst io.Writer, src io.Reader, buf []byte) (int64, error) {
+	return io.CopyBuffer(struct{ io.Writer }{dst}, struct{ io.Reader }{src}, buf)
+}
+
+// SanitizeArchivePath joins the archive path t to the destination directory d
+// and returns an error if the result escapes d. This protects from the
+// "G305: Zip Slip vulnerability":
 // https://security.snyk.io/research/zip-slip-vulnerability
+//
+// The check works on path elements rather than string prefixes, which means
+// that a sibling like "/tmp/foobar" is not considered to be within "/tmp/foo".
+// Absolute archive paths are placed below d.
 func SanitizeArchivePath(d, t string) (v string, err error) {
-	v = filepath.Join(d, t)
-	if strings.HasPrefix(v, filepath.Clean(d)) {
-		return v, nil
+	v, err = util.SafeJoin(d, t)
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", "content filepath is tainted", t)
+	}
+
+	return v, nil
+}
+
+// List returns the headers of all entries of the provided `tarFilePath`
+// without extracting it, similar to `tar -tvf`. The returned headers are
+// copies, which are safe to use after the function returned.
+func List(tarFilePath string) ([]*tar.Header, error) {
+	headers := []*tar.Header{}
+
+	if err := iterateTarball(
+		tarFilePath,
+		func(_ *tar.Reader, header *tar.Header) (stop bool, err error) {
+			headerCopy := *header
+			headerCopy.PAXRecords = maps.Clone(header.PAXRecords)
+			headers = append(headers, &headerCopy)
+
+			return false, nil
+		},
+	); err != nil {
+		return nil, err
 	}
 
-	return "", fmt.Errorf("%s: %s", "content filepath is tainted", t)
+	return headers, nil
 }
 
 // ReadFileFromGzippedTar opens a tarball and reads contents of a file inside.
+// Despite its name, all supported compression formats are detected
+// automatically. The contents are read into memory, which means that the
+// returned reader stays valid after the tarball got closed. Use
+// WithMaxFileSize to limit the memory usage, which fails with
+// ErrMaxFileSizeExceeded before reading a larger file. All other options are
+// ignored.
 func ReadFileFromGzippedTar(
-	tarPath, filePath string,
+	tarPath, filePath string, opts ...Option,
 ) (res io.Reader, err error) {
+	o := newOptions(opts...)
+
 	if err := iterateTarball(
 		tarPath,
 		func(reader *tar.Reader, header *tar.Header) (stop bool, err error) {
 			if header.Name == filePath {
-				res = reader
+				// The tar reader never returns more than the declared size
+				if o.maxFileSize > 0 && header.Size > o.maxFileSize {
+					return false, fmt.Errorf(
+						"%w: %s declares %d bytes, limit is %d bytes",
+						ErrMaxFileSizeExceeded, header.Name, header.Size, o.maxFileSize,
+					)
+				}
+
+				data, err := io.ReadAll(reader)
+				if err != nil {
+					return false, fmt.Errorf("read file %s: %w", filePath, err)
+				}
+
+				res = bytes.NewReader(data)
 
 				return true, nil
 			}
@@ -247,12 +662,54 @@ func ReadFileFromGzippedTar(
 	}
 
 	if res == nil {
-		return nil, fmt.Errorf("unable to find file %q in tarball %q: %w", tarPath, filePath, err)
+		return nil, fmt.Errorf("unable to find file %q in tarball %q: %w", filePath, tarPath, ErrMemberNotFound)
 	}
 
 	return res, nil
 }
 
+// ErrMemberNotFound is returned if a member does not exist in a tarball.
+var ErrMemberNotFound = errors.New("member not found in tarball")
+
+// ErrMaxSizeExceeded is returned when extracting a tarball whose contents
+// exceed the size set via WithMaxSize.
+var ErrMaxSizeExceeded = errors.New("maximum extracted size exceeded")
+
+// ErrMaxFileSizeExceeded is returned when extracting a tarball containing a
+// file which exceeds the size set via WithMaxFileSize.
+var ErrMaxFileSizeExceeded = errors.New("maximum extracted file size exceeded")
+
+// SHA256ForMember returns the hex-encoded sha256 hash of the file
+// `memberPath` inside of the tarball `tarFilePath` without extracting it.
+// Returns `ErrMemberNotFound` if the tarball does not contain the member.
+func SHA256ForMember(tarFilePath, memberPath string) (string, error) {
+	var digest string
+
+	if err := iterateTarball(
+		tarFilePath,
+		func(reader *tar.Reader, header *tar.Header) (stop bool, err error) {
+			if header.Name != memberPath {
+				return false, nil
+			}
+
+			digest, err = hash.SHA256ForReader(reader)
+			if err != nil {
+				return false, fmt.Errorf("hash member %s: %w", memberPath, err)
+			}
+
+			return true, nil
+		},
+	); err != nil {
+		return "", err
+	}
+
+	if digest == "" {
+		return "", fmt.Errorf("%w: %s", ErrMemberNotFound, memberPath)
+	}
+
+	return digest, nil
+}
+
 // iterateTarball can be used to iterate over the contents of a tarball by
 // calling the callback for each entry.
 func iterateTarball(
@@ -263,13 +720,56 @@ func iterateTarball(
 	if err != nil {
 		return fmt.Errorf("opening tar file %q: %w", tarPath, err)
 	}
+	defer file.Close()
 
-	gzipReader, err := gzip.NewReader(file)
-	if err != nil {
-		return fmt.Errorf("creating gzip reader for file %q: %w", tarPath, err)
+	if err := iterateTarballReader(file, callback); err != nil {
+		return fmt.Errorf("reading tar file %q: %w", tarPath, err)
 	}
 
-	tarReader := tar.NewReader(gzipReader)
+	return nil
+}
+
+var (
+	// gzipMagic are the leading bytes of gzipped data.
+	gzipMagic = []byte{0x1f, 0x8b}
+
+	// zstdMagic are the leading bytes of zstd compressed data.
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// iterateTarballReader behaves like iterateTarball, but reads the tarball
+// from the provided reader. Gzipped and zstd compressed tarballs are detected
+// by their leading bytes, while all other data is read as plain tarball.
+func iterateTarballReader(
+	r io.Reader,
+	callback func(*tar.Reader, *tar.Header) (stop bool, err error),
+) error {
+	br := bufio.NewReader(r)
+
+	var tr io.Reader = br
+
+	// Shorter data than the magic is treated as plain tarball
+	magic, _ := br.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gzipReader, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("creating gzip reader: %w", err)
+		}
+
+		tr = gzipReader
+	case bytes.HasPrefix(magic, zstdMagic):
+		zstdReader, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return fmt.Errorf("creating zstd reader: %w", err)
+		}
+		defer zstdReader.Close()
+
+		tr = zstdReader
+	}
+
+	tarReader := tar.NewReader(tr)
 
 	for {
 		tarHeader, err := tarReader.Next()
@@ -277,6 +777,10 @@ func iterateTarball(
 			break // End of archive
 		}
 
+		if err != nil {
+			return fmt.Errorf("reading tar header: %w", err)
+		}
+
 		stop, err := callback(tarReader, tarHeader)
 		if err != nil {
 			return err
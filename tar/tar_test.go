@@ -18,11 +18,19 @@ package tar
 
 import (
 	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
@@ -70,7 +78,7 @@ func TestCompress(t *testing.T) {
 	require.NoError(t, Compress(tarFilePath, baseTmpDir, excludes...))
 	require.FileExists(t, tarFilePath)
 
-	res := []string{"1.txt", "2.bin", "sub/4.txt", "sub/link"}
+	res := []string{"1.txt", "2.bin", "sub/", "sub/4.txt", "sub/link"}
 
 	require.NoError(t, iterateTarball(
 		tarFilePath, func(_ *tar.Reader, header *tar.Header) (bool, error) {
@@ -115,6 +123,61 @@ func TestCompressWithoutPreservingPath(t *testing.T) {
 	)
 }
 
+func TestCompressDereferenceSymlinks(t *testing.T) {
+	baseTmpDir := t.TempDir()
+	targetDir := t.TempDir()
+	contentsDir := filepath.Join(baseTmpDir, "contents")
+	require.NoError(t, os.MkdirAll(contentsDir, os.FileMode(0o755)))
+
+	require.NoError(t, os.WriteFile(
+		filepath.Join(contentsDir, "1.txt"), []byte{1}, os.FileMode(0o644),
+	))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(targetDir, "2.txt"), []byte{2}, os.FileMode(0o644),
+	))
+	require.NoError(t, os.Symlink(
+		filepath.Join(targetDir, "2.txt"), filepath.Join(contentsDir, "file-link"),
+	))
+	require.NoError(t, os.Symlink(targetDir, filepath.Join(contentsDir, "dir-link")))
+
+	tarFilePath := filepath.Join(baseTmpDir, "res.tar.gz")
+	require.NoError(t, CompressDereferenceSymlinks(tarFilePath, contentsDir))
+	require.FileExists(t, tarFilePath)
+
+	res := map[string]byte{
+		"contents/1.txt":          1,
+		"contents/dir-link/2.txt": 2,
+		"contents/file-link":      2,
+	}
+
+	require.NoError(t, iterateTarball(
+		tarFilePath, func(reader *tar.Reader, header *tar.Header) (bool, error) {
+			if header.Typeflag == tar.TypeDir {
+				require.Contains(t, []string{"contents/", "contents/dir-link/"}, header.Name)
+
+				return false, nil
+			}
+
+			expected, ok := res[header.Name]
+			require.True(t, ok, "unexpected file %s", header.Name)
+			require.Equal(t, byte(tar.TypeReg), header.Typeflag)
+
+			content, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			require.Equal(t, []byte{expected}, content)
+
+			delete(res, header.Name)
+
+			return false, nil
+		}),
+	)
+	require.Empty(t, res)
+
+	// Symlink cycles have to fail
+	require.NoError(t, os.Symlink(contentsDir, filepath.Join(targetDir, "cycle")))
+	require.Error(t, CompressDereferenceSymlinks(tarFilePath, contentsDir))
+}
+
 func TestExtract(t *testing.T) {
 	tarball := []byte{
 		0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0xec, 0xd7,
@@ -171,6 +234,118 @@ func TestExtract(t *testing.T) {
 	))
 }
 
+func TestCompressExtractEmptyDirectory(t *testing.T) {
+	baseTmpDir := t.TempDir()
+	contentsDir := filepath.Join(baseTmpDir, "contents")
+
+	for _, dir := range []string{"cache", "logs/old", "excluded"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(contentsDir, dir), os.FileMode(0o755)))
+	}
+
+	require.NoError(t, os.Chmod(filepath.Join(contentsDir, "cache"), 0o700))
+
+	for _, preserve := range []bool{true, false} {
+		tarFilePath := filepath.Join(baseTmpDir, "res.tar.gz")
+		require.NoError(t, CompressWithOptions(
+			tarFilePath, contentsDir,
+			WithPreserveRootDirStructure(preserve),
+			WithExcludes(regexp.MustCompile("excluded$")),
+		))
+
+		destDir := t.TempDir()
+		require.NoError(t, Extract(tarFilePath, destDir))
+
+		root := destDir
+		if preserve {
+			root = filepath.Join(destDir, "contents")
+		}
+
+		require.DirExists(t, filepath.Join(root, "logs", "old"))
+		require.NoDirExists(t, filepath.Join(root, "excluded"))
+
+		info, err := os.Stat(filepath.Join(root, "cache"))
+		require.NoError(t, err)
+		require.True(t, info.IsDir())
+
+		if runtime.GOOS != "windows" {
+			require.Equal(t, os.FileMode(0o700), info.Mode().Perm())
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	baseTmpDir := t.TempDir()
+	contentsDir := filepath.Join(baseTmpDir, "contents")
+	require.NoError(t, os.MkdirAll(filepath.Join(contentsDir, "sub"), os.FileMode(0o755)))
+
+	for name, content := range map[string]string{"a.txt": "a", "sub/b.txt": "bbb"} {
+		require.NoError(t, os.WriteFile(
+			filepath.Join(contentsDir, name), []byte(content), os.FileMode(0o644),
+		))
+	}
+
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(contentsDir, "link")))
+
+	tarFilePath := filepath.Join(baseTmpDir, "res.tar.gz")
+	require.NoError(t, CompressWithoutPreservingPath(tarFilePath, contentsDir))
+
+	headers, err := List(tarFilePath)
+	require.NoError(t, err)
+
+	type entry struct {
+		name     string
+		typeflag byte
+		size     int64
+		linkname string
+	}
+
+	res := []entry{}
+	for _, header := range headers {
+		res = append(res, entry{header.Name, header.Typeflag, header.Size, header.Linkname})
+	}
+
+	require.Equal(t, []entry{
+		{"a.txt", tar.TypeReg, 1, ""},
+		{"link", tar.TypeSymlink, 0, "a.txt"},
+		{"sub/", tar.TypeDir, 0, ""},
+		{"sub/b.txt", tar.TypeReg, 3, ""},
+	}, res)
+
+	_, err = List(filepath.Join(baseTmpDir, "missing.tar.gz"))
+	require.Error(t, err)
+}
+
+func TestSanitizeArchivePath(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "foo")
+
+	for _, tc := range []struct {
+		name        string
+		expected    string
+		shouldError bool
+	}{
+		{name: "file.txt", expected: filepath.Join(dest, "file.txt")},
+		{name: "sub/../file.txt", expected: filepath.Join(dest, "file.txt")},
+		{name: "sub/", expected: filepath.Join(dest, "sub")},
+		{name: ".", expected: dest},
+		{name: "/etc/passwd", expected: filepath.Join(dest, "etc", "passwd")},
+		{name: "../foobar/file.txt", shouldError: true},
+		{name: "../foo", expected: dest},
+		{name: "../file.txt", shouldError: true},
+		{name: "sub/../../file.txt", shouldError: true},
+		{name: "..", shouldError: true},
+	} {
+		res, err := SanitizeArchivePath(dest, tc.name)
+		if tc.shouldError {
+			require.Error(t, err, tc.name)
+
+			continue
+		}
+
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expected, res, tc.name)
+	}
+}
+
 func TestReadFileFromGzippedTar(t *testing.T) {
 	baseTmpDir := t.TempDir()
 
@@ -223,7 +398,11 @@ func TestReadFileFromGzippedTar(t *testing.T) {
 			r, err := ReadFileFromGzippedTar(tc.args.tarPath, tc.args.filePath)
 			if tc.want.shouldErr {
 				require.Nil(t, r)
-				require.Error(t, err)
+				require.ErrorIs(t, err, ErrMemberNotFound)
+				require.EqualError(t, err, fmt.Sprintf(
+					"unable to find file %q in tarball %q: %v",
+					tc.args.filePath, tc.args.tarPath, ErrMemberNotFound,
+				))
 			} else {
 				file, err := io.ReadAll(r)
 				require.NoError(t, err)
@@ -231,4 +410,498 @@ func TestReadFileFromGzippedTar(t *testing.T) {
 			}
 		})
 	}
+
+	r, err := ReadFileFromGzippedTar(
+		testTarPath, testFilePath, WithMaxFileSize(int64(len(testFileContents))),
+	)
+	require.NoError(t, err)
+	file, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, testFileContents, string(file))
+
+	r, err = ReadFileFromGzippedTar(
+		testTarPath, testFilePath, WithMaxFileSize(int64(len(testFileContents)-1)),
+	)
+	require.ErrorIs(t, err, ErrMaxFileSizeExceeded)
+	require.Nil(t, r)
+}
+
+func TestCompressExtractWithBufferSize(t *testing.T) {
+	baseTmpDir := t.TempDir()
+	contentsDir := filepath.Join(baseTmpDir, "contents")
+	require.NoError(t, os.MkdirAll(contentsDir, os.FileMode(0o755)))
+
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	require.NoError(t, os.WriteFile(
+		filepath.Join(contentsDir, "file.txt"), content, os.FileMode(0o644),
+	))
+
+	tarFilePath := filepath.Join(baseTmpDir, "res.tar.gz")
+	require.NoError(t, CompressWithOptions(
+		tarFilePath, contentsDir,
+		WithPreserveRootDirStructure(false),
+		WithBufferSize(7),
+	))
+
+	destDir := filepath.Join(baseTmpDir, "dest")
+	require.NoError(t, ExtractWithOptions(tarFilePath, destDir, WithBufferSize(13)))
+
+	res, err := os.ReadFile(filepath.Join(destDir, "file.txt"))
+	require.NoError(t, err)
+	require.Equal(t, content, res)
+}
+
+func TestExtractFromReader(t *testing.T) {
+	baseTmpDir := t.TempDir()
+	contentsDir := filepath.Join(baseTmpDir, "contents")
+	require.NoError(t, os.MkdirAll(filepath.Join(contentsDir, "sub"), os.FileMode(0o755)))
+
+	for _, name := range []string{"a.txt", "sub/b.txt"} {
+		require.NoError(t, os.WriteFile(
+			filepath.Join(contentsDir, name), []byte("12345"), os.FileMode(0o644),
+		))
+	}
+
+	tarFilePath := filepath.Join(baseTmpDir, "res.tar.gz")
+	require.NoError(t, CompressWithoutPreservingPath(tarFilePath, contentsDir))
+
+	tarball, err := os.ReadFile(tarFilePath)
+	require.NoError(t, err)
+
+	destDir := filepath.Join(baseTmpDir, "dest")
+	require.NoError(t, ExtractFromReader(bytes.NewReader(tarball), destDir, WithMaxSize(10)))
+
+	res, err := os.ReadFile(filepath.Join(destDir, "sub", "b.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "12345", string(res))
+
+	// Exceeding the maximum size
+	err = ExtractFromReader(bytes.NewReader(tarball), t.TempDir(), WithMaxSize(9))
+	require.ErrorIs(t, err, ErrMaxSizeExceeded)
+
+	// Truncated tarballs
+	err = ExtractFromReader(bytes.NewReader(tarball[:len(tarball)/2]), t.TempDir())
+	require.Error(t, err)
+}
+
+func TestExtractWithStripComponents(t *testing.T) {
+	baseTmpDir := t.TempDir()
+	contentsDir := filepath.Join(baseTmpDir, "contents")
+	wrapperDir := filepath.Join(contentsDir, "release-v1.0.0")
+	require.NoError(t, os.MkdirAll(filepath.Join(wrapperDir, "sub"), os.FileMode(0o755)))
+
+	for _, name := range []string{"README", "release-v1.0.0/a.txt", "release-v1.0.0/sub/b.txt"} {
+		require.NoError(t, os.WriteFile(
+			filepath.Join(contentsDir, name), []byte(name), os.FileMode(0o644),
+		))
+	}
+
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(wrapperDir, "link")))
+
+	tarFilePath := filepath.Join(baseTmpDir, "res.tar.gz")
+	require.NoError(t, CompressWithoutPreservingPath(tarFilePath, contentsDir))
+
+	destDir := filepath.Join(baseTmpDir, "dest")
+	require.NoError(t, ExtractWithOptions(tarFilePath, destDir, WithStripComponents(1)))
+
+	for name, expected := range map[string]string{
+		"a.txt":     "release-v1.0.0/a.txt",
+		"sub/b.txt": "release-v1.0.0/sub/b.txt",
+		"link":      "release-v1.0.0/a.txt",
+	} {
+		res, err := os.ReadFile(filepath.Join(destDir, name))
+		require.NoError(t, err)
+		require.Equal(t, expected, string(res))
+	}
+
+	// Files with less path components are skipped
+	require.NoFileExists(t, filepath.Join(destDir, "README"))
+	require.NoDirExists(t, filepath.Join(destDir, "release-v1.0.0"))
+
+	// Stripping all components skips everything
+	destDir = filepath.Join(baseTmpDir, "empty")
+	require.NoError(t, ExtractWithOptions(tarFilePath, destDir, WithStripComponents(3)))
+	require.NoDirExists(t, destDir)
+
+	// Extract keeps the full hierarchy
+	destDir = filepath.Join(baseTmpDir, "full")
+	require.NoError(t, Extract(tarFilePath, destDir))
+	require.FileExists(t, filepath.Join(destDir, "README"))
+	require.FileExists(t, filepath.Join(destDir, "release-v1.0.0", "sub", "b.txt"))
+}
+
+func TestExtractFiles(t *testing.T) {
+	baseTmpDir := t.TempDir()
+	tarFilePath := filepath.Join(baseTmpDir, "res.tar.gz")
+
+	tarFile, err := os.Create(tarFilePath)
+	require.NoError(t, err)
+
+	gzipWriter := gzip.NewWriter(tarFile)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for _, name := range []string{"a.txt", "sub/", "sub/b.txt", "sub.txt", "sub/sub.txt"} {
+		if strings.HasSuffix(name, "/") {
+			require.NoError(t, tarWriter.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeDir, Name: name, Mode: 0o755,
+			}))
+
+			continue
+		}
+
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg, Name: name, Mode: 0o644, Size: int64(len(name)),
+		}))
+		_, err := tarWriter.Write([]byte(name))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+	require.NoError(t, tarFile.Close())
+
+	for _, tc := range []struct {
+		names    []string
+		expected []string
+		missing  bool
+	}{
+		{ // found subset
+			names:    []string{"a.txt", "sub/b.txt"},
+			expected: []string{"a.txt", "sub", "sub/b.txt"},
+		},
+		{ // file next to a directory with the same prefix
+			names:    []string{"sub.txt"},
+			expected: []string{"sub.txt"},
+		},
+		{ // directory without its contents
+			names:    []string{"sub"},
+			expected: []string{"sub"},
+		},
+		{ // directory with trailing slash and a nested file
+			names:    []string{"sub/", "sub/sub.txt"},
+			expected: []string{"sub", "sub/sub.txt"},
+		},
+		{ // missing name
+			names:    []string{"a.txt", "missing.txt"},
+			expected: []string{"a.txt"},
+			missing:  true,
+		},
+	} {
+		destDir := t.TempDir()
+
+		err := ExtractFiles(tarFilePath, destDir, tc.names)
+		if tc.missing {
+			require.ErrorIs(t, err, ErrMemberNotFound)
+			require.Contains(t, err.Error(), "missing.txt")
+		} else {
+			require.NoError(t, err)
+		}
+
+		extracted := []string{}
+		require.NoError(t, filepath.WalkDir(destDir, func(path string, _ fs.DirEntry, err error) error {
+			if err != nil || path == destDir {
+				return err
+			}
+
+			rel, err := filepath.Rel(destDir, path)
+			extracted = append(extracted, filepath.ToSlash(rel))
+
+			return err
+		}))
+		require.ElementsMatch(t, tc.expected, extracted, tc.names)
+	}
+
+	require.Error(t, ExtractFiles(filepath.Join(baseTmpDir, "missing.tar.gz"), t.TempDir(), []string{"a.txt"}))
+}
+
+func TestCompressExtractWithoutCompression(t *testing.T) {
+	baseTmpDir := t.TempDir()
+	contentsDir := filepath.Join(baseTmpDir, "contents")
+	require.NoError(t, os.MkdirAll(filepath.Join(contentsDir, "sub"), os.FileMode(0o755)))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(contentsDir, "sub", "a.txt"), []byte("12345"), os.FileMode(0o644),
+	))
+
+	tarFilePath := filepath.Join(baseTmpDir, "res.tar")
+	require.NoError(t, CompressWithOptions(
+		tarFilePath, contentsDir,
+		WithPreserveRootDirStructure(false),
+		WithCompression(CompressionNone),
+	))
+
+	// The result is a plain tarball
+	file, err := os.Open(tarFilePath)
+	require.NoError(t, err)
+
+	defer file.Close()
+
+	header, err := tar.NewReader(file).Next()
+	require.NoError(t, err)
+	require.Equal(t, "sub/", header.Name)
+
+	destDir := filepath.Join(baseTmpDir, "dest")
+	require.NoError(t, Extract(tarFilePath, destDir))
+
+	res, err := os.ReadFile(filepath.Join(destDir, "sub", "a.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "12345", string(res))
+
+	r, err := ReadFileFromGzippedTar(tarFilePath, "sub/a.txt")
+	require.NoError(t, err)
+
+	res, err = io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "12345", string(res))
+
+	require.Error(t, CompressWithOptions(
+		filepath.Join(baseTmpDir, "invalid.tar"), contentsDir, WithCompression(-1),
+	))
+}
+
+func TestCompressExtractRoundTrip(t *testing.T) {
+	baseTmpDir := t.TempDir()
+	contentsDir := filepath.Join(baseTmpDir, "contents")
+	require.NoError(t, os.MkdirAll(filepath.Join(contentsDir, "sub"), os.FileMode(0o755)))
+
+	files := map[string]string{
+		"a.txt":     strings.Repeat("compressible ", 1000),
+		"sub/b.txt": "12345",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(
+			filepath.Join(contentsDir, name), []byte(content), os.FileMode(0o644),
+		))
+	}
+
+	for _, tc := range []struct {
+		name  string
+		opts  []Option
+		magic []byte
+	}{
+		{name: "gzip", magic: []byte{0x1f, 0x8b}},
+		{name: "gzip best", opts: []Option{WithCompressionLevel(9)}, magic: []byte{0x1f, 0x8b}},
+		{name: "none", opts: []Option{WithCompression(CompressionNone)}, magic: []byte("a.txt")},
+		{name: "zstd", opts: []Option{WithCompression(CompressionZstd)}, magic: []byte{0x28, 0xb5, 0x2f, 0xfd}},
+		{
+			name:  "zstd fastest",
+			opts:  []Option{WithCompression(CompressionZstd), WithCompressionLevel(1)},
+			magic: []byte{0x28, 0xb5, 0x2f, 0xfd},
+		},
+	} {
+		tarFilePath := filepath.Join(baseTmpDir, tc.name+".tar")
+		require.NoError(t, CompressWithOptions(
+			tarFilePath, contentsDir, append(tc.opts, WithPreserveRootDirStructure(false))...,
+		), tc.name)
+
+		tarball, err := os.ReadFile(tarFilePath)
+		require.NoError(t, err)
+
+		require.True(t, bytes.HasPrefix(tarball, tc.magic), tc.name)
+
+		destDir := filepath.Join(baseTmpDir, tc.name)
+		require.NoError(t, Extract(tarFilePath, destDir), tc.name)
+
+		for name, content := range files {
+			res, err := os.ReadFile(filepath.Join(destDir, name))
+			require.NoError(t, err)
+			require.Equal(t, content, string(res), tc.name)
+
+			r, err := ReadFileFromGzippedTar(tarFilePath, name)
+			require.NoError(t, err)
+
+			res, err = io.ReadAll(r)
+			require.NoError(t, err)
+			require.Equal(t, content, string(res), tc.name)
+		}
+	}
+
+	// Invalid gzip level
+	require.Error(t, CompressWithOptions(
+		filepath.Join(baseTmpDir, "invalid.tar.gz"), contentsDir, WithCompressionLevel(42),
+	))
+}
+
+func TestExtractPreserveMetadata(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ownership is not supported on windows")
+	}
+
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	tarFilePath := filepath.Join(t.TempDir(), "res.tar")
+
+	tarFile, err := os.Create(tarFilePath)
+	require.NoError(t, err)
+
+	tarWriter := tar.NewWriter(tarFile)
+	for _, header := range []*tar.Header{
+		{Typeflag: tar.TypeDir, Name: "dir/", Mode: 0o755},
+		{Typeflag: tar.TypeReg, Name: "dir/file", Mode: 0o644, Size: 4},
+		{Typeflag: tar.TypeSymlink, Name: "dir/link", Linkname: "file"},
+	} {
+		header.ModTime = modTime
+		header.Uid = os.Getuid()
+		header.Gid = os.Getgid()
+		require.NoError(t, tarWriter.WriteHeader(header))
+
+		if header.Size > 0 {
+			_, err := tarWriter.Write([]byte("test"))
+			require.NoError(t, err)
+		}
+	}
+
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, tarFile.Close())
+
+	// Times are preserved by default
+	destDir := t.TempDir()
+	require.NoError(t, ExtractWithOptions(tarFilePath, destDir, WithPreserveOwners(true)))
+
+	for _, name := range []string{"dir", "dir/file"} {
+		info, err := os.Stat(filepath.Join(destDir, name))
+		require.NoError(t, err)
+		require.True(t, modTime.Equal(info.ModTime()), name)
+	}
+
+	// Disabled preservation keeps the time of extraction
+	destDir = t.TempDir()
+	require.NoError(t, ExtractWithOptions(tarFilePath, destDir, WithPreserveTimes(false)))
+
+	info, err := os.Stat(filepath.Join(destDir, "dir", "file"))
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), info.ModTime(), time.Minute)
+}
+
+func TestOnEntry(t *testing.T) {
+	baseTmpDir := t.TempDir()
+	contentsDir := filepath.Join(baseTmpDir, "contents")
+	require.NoError(t, os.MkdirAll(filepath.Join(contentsDir, "sub"), os.FileMode(0o755)))
+
+	for _, name := range []string{"a.txt", "b.log", "sub/c.txt"} {
+		require.NoError(t, os.WriteFile(
+			filepath.Join(contentsDir, name), []byte(name), os.FileMode(0o644),
+		))
+	}
+
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(contentsDir, "link")))
+
+	compressed := []string{}
+	tarFilePath := filepath.Join(baseTmpDir, "res.tar.gz")
+	require.NoError(t, CompressWithOptions(
+		tarFilePath, contentsDir,
+		WithPreserveRootDirStructure(false),
+		WithExcludes(regexp.MustCompile(`\.log$`)),
+		WithOnEntry(func(header *tar.Header) { compressed = append(compressed, header.Name) }),
+	))
+	require.Equal(t, []string{"a.txt", "link", "sub/", "sub/c.txt"}, compressed)
+
+	extracted := []string{}
+	require.NoError(t, ExtractWithOptions(
+		tarFilePath, t.TempDir(),
+		WithOnEntry(func(header *tar.Header) { extracted = append(extracted, header.Name) }),
+	))
+	require.Equal(t, compressed, extracted)
+}
+
+func TestExtractSizeLimits(t *testing.T) {
+	tarFilePath := filepath.Join(t.TempDir(), "bomb.tar.gz")
+
+	tarFile, err := os.Create(tarFilePath)
+	require.NoError(t, err)
+
+	gzipWriter := gzip.NewWriter(tarFile)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	// Highly compressible contents which expand a lot
+	for name, size := range map[string]int{"a": 10, "b": 1024 * 1024} {
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg, Name: name, Mode: 0o644, Size: int64(size),
+		}))
+		_, err := tarWriter.Write(bytes.Repeat([]byte{0}, size))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+	require.NoError(t, tarFile.Close())
+
+	for _, tc := range []struct {
+		opts        []Option
+		expectedErr error
+	}{
+		{},
+		{opts: []Option{WithMaxFileSize(1024 * 1024), WithMaxSize(1024*1024 + 10)}},
+		{opts: []Option{WithMaxFileSize(1024)}, expectedErr: ErrMaxFileSizeExceeded},
+		{opts: []Option{WithMaxSize(1024)}, expectedErr: ErrMaxSizeExceeded},
+	} {
+		destDir := t.TempDir()
+
+		err := ExtractWithOptions(tarFilePath, destDir, tc.opts...)
+		if tc.expectedErr == nil {
+			require.NoError(t, err)
+			require.FileExists(t, filepath.Join(destDir, "b"))
+
+			continue
+		}
+
+		require.ErrorIs(t, err, tc.expectedErr)
+		require.Contains(t, err.Error(), "b declares 1048576 bytes")
+
+		// The file exceeding the limit has not been written
+		require.NoFileExists(t, filepath.Join(destDir, "b"))
+	}
+}
+
+func BenchmarkCompressExtractBufferSize(b *testing.B) {
+	baseTmpDir := b.TempDir()
+	contentsDir := filepath.Join(baseTmpDir, "contents")
+	require.NoError(b, os.MkdirAll(contentsDir, os.FileMode(0o755)))
+
+	const fileSize = 64 * 1024 * 1024
+
+	require.NoError(b, os.WriteFile(
+		filepath.Join(contentsDir, "file.bin"),
+		bytes.Repeat([]byte{1, 2, 3, 4}, fileSize/4),
+		os.FileMode(0o644),
+	))
+
+	for _, size := range []int{DefaultBufferSize, 1024 * 1024} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			b.SetBytes(fileSize)
+
+			for range b.N {
+				tarFilePath := filepath.Join(baseTmpDir, "res.tar.gz")
+				require.NoError(b, CompressWithOptions(
+					tarFilePath, contentsDir,
+					WithPreserveRootDirStructure(false),
+					WithBufferSize(size),
+				))
+				require.NoError(b, ExtractWithOptions(
+					tarFilePath, b.TempDir(), WithBufferSize(size),
+				))
+			}
+		})
+	}
+}
+
+func TestSHA256ForMember(t *testing.T) {
+	baseTmpDir := t.TempDir()
+	contentsDir := filepath.Join(baseTmpDir, "contents")
+	require.NoError(t, os.MkdirAll(filepath.Join(contentsDir, "sub"), os.FileMode(0o755)))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(contentsDir, "sub", "test.txt"), []byte("test"), os.FileMode(0o644),
+	))
+
+	tarFilePath := filepath.Join(baseTmpDir, "res.tar.gz")
+	require.NoError(t, CompressWithoutPreservingPath(tarFilePath, contentsDir))
+
+	res, err := SHA256ForMember(tarFilePath, "sub/test.txt")
+	require.NoError(t, err)
+	require.Equal(t, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", res)
+
+	_, err = SHA256ForMember(tarFilePath, "not-there.txt")
+	require.ErrorIs(t, err, ErrMemberNotFound)
+
+	_, err = SHA256ForMember(filepath.Join(baseTmpDir, "not-there.tar.gz"), "sub/test.txt")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrMemberNotFound)
 }
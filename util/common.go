@@ -18,20 +18,31 @@ package util
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/blang/semver/v4"
+	"github.com/moby/term"
+	"github.com/pmezard/go-difflib/difflib"
 	"github.com/sirupsen/logrus"
 
 	"sigs.k8s.io/release-utils/command"
+	"sigs.k8s.io/release-utils/hash"
+	"sigs.k8s.io/release-utils/internal/redact"
 )
 
 const (
@@ -39,10 +50,8 @@ const (
 )
 
 var (
-	regexpCRLF       = regexp.MustCompile(`\015$`)
-	regexpCtrlChar   = regexp.MustCompile(`\x1B[\[(](\d{1,2}(;\d{1,2})?)?[mKB]`)
-	regexpOauthToken = regexp.MustCompile(`[a-f0-9]{40}:x-oauth-basic`)
-	regexpGitToken   = regexp.MustCompile(`git:[a-f0-9]{35,40}@github\.com`)
+	regexpCRLF     = regexp.MustCompile(`\015$`)
+	regexpCtrlChar = regexp.MustCompile(`\x1B[\[(](\d{1,2}(;\d{1,2})?)?[mKB]`)
 )
 
 // UserInputError a custom error to handle more user input info.
@@ -401,6 +410,79 @@ func SemverToTagString(tag semver.Version) string {
 	return AddTagPrefix(tag.String())
 }
 
+// SortTags parses the semver tags, removes duplicates and returns them in
+// ascending precedence order. Tags are considered duplicates if they refer to
+// the same version, like "v1.2.3" and "1.2.3", where the first occurrence is
+// kept as is. An error naming all unparseable tags is returned if any.
+func SortTags(tags []string) ([]string, error) {
+	type parsedTag struct {
+		tag     string
+		version semver.Version
+	}
+
+	parsed := make([]parsedTag, 0, len(tags))
+	seen := map[string]struct{}{}
+	invalid := []string{}
+
+	for _, tag := range tags {
+		version, err := TagStringToSemver(tag)
+		if err != nil {
+			invalid = append(invalid, tag)
+
+			continue
+		}
+
+		if _, ok := seen[version.String()]; ok {
+			continue
+		}
+
+		seen[version.String()] = struct{}{}
+		parsed = append(parsed, parsedTag{tag: tag, version: version})
+	}
+
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("unable to parse semver tags: %s", strings.Join(invalid, ", "))
+	}
+
+	slices.SortStableFunc(parsed, func(a, b parsedTag) int {
+		return a.version.Compare(b.version)
+	})
+
+	sorted := make([]string, 0, len(parsed))
+	for _, p := range parsed {
+		sorted = append(sorted, p.tag)
+	}
+
+	return sorted, nil
+}
+
+// ParseRepoSpec parses a GitHub style repository spec into its owner, repo
+// and optional version. Supported forms are "owner/repo", "owner/repo@v1.2.3"
+// as well as full module paths like "github.com/owner/repo/cmd/tool@v1.2.3".
+// The first path element is considered to be a host if it contains a dot,
+// while all elements after owner and repo are ignored. The version is
+// returned as provided, but it has to be a valid semantic version with an
+// optional "v" prefix.
+func ParseRepoSpec(spec string) (owner, repo, version string, err error) {
+	path, version, hasVersion := strings.Cut(spec, "@")
+	if hasVersion {
+		if _, err := TagStringToSemver(version); err != nil {
+			return "", "", "", fmt.Errorf("invalid version in spec %q: %w", spec, err)
+		}
+	}
+
+	parts := strings.Split(path, "/")
+	if strings.Contains(parts[0], ".") {
+		parts = parts[1:]
+	}
+
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", "", fmt.Errorf("invalid spec %q: expected owner/repo[@version]", spec)
+	}
+
+	return parts[0], parts[1], version, nil
+}
+
 // CopyFileLocal copies a local file from one local location to another.
 func CopyFileLocal(src, dst string, required bool) error {
 	logrus.Infof("Trying to copy file %s to %s (required: %v)", src, dst, required)
@@ -510,6 +592,121 @@ func RemoveAndReplaceDir(path string) error {
 	return nil
 }
 
+// UpdateSymlink atomically points linkPath to target. It creates a temporary
+// symlink next to linkPath and renames it over linkPath, which means readers
+// either observe the old or the new link, but never a missing one. An error is
+// returned if linkPath exists but is not a symlink.
+func UpdateSymlink(target, linkPath string) error {
+	info, err := os.Lstat(linkPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("stat %s: %w", linkPath, err)
+	}
+
+	if err == nil && info.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf(
+			"refusing to replace %s: exists and is not a symlink", linkPath,
+		)
+	}
+
+	tmpLink := filepath.Join(
+		filepath.Dir(linkPath),
+		fmt.Sprintf(".%s.tmp-%d", filepath.Base(linkPath), time.Now().UnixNano()),
+	)
+
+	if err := os.Symlink(target, tmpLink); err != nil {
+		return fmt.Errorf("create temporary symlink %s: %w", tmpLink, err)
+	}
+
+	if err := os.Rename(tmpLink, linkPath); err != nil {
+		if removeErr := os.Remove(tmpLink); removeErr != nil {
+			logrus.Warnf("Unable to remove temporary symlink %s: %v", tmpLink, removeErr)
+		}
+
+		return fmt.Errorf("rename %s to %s: %w", tmpLink, linkPath, err)
+	}
+
+	return nil
+}
+
+// SafeRemoveAll removes the target path and any children it contains, but
+// only if target is strictly within the base directory. Both paths are
+// resolved before the check, which guards against ".." traversal as well as
+// symlinks escaping the base directory. Removing a non-existing target is not
+// considered an error.
+func SafeRemoveAll(base, target string) error {
+	resolvedBase, err := resolvePath(base)
+	if err != nil {
+		return fmt.Errorf("resolve base path %s: %w", base, err)
+	}
+
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return fmt.Errorf("get absolute target path %s: %w", target, err)
+	}
+
+	// Resolve the parent only: a symlink as target gets removed itself and
+	// does not remove the link destination.
+	resolvedParent, err := resolvePath(filepath.Dir(absTarget))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("resolve target path %s: %w", target, err)
+	}
+
+	resolvedTarget := filepath.Join(resolvedParent, filepath.Base(absTarget))
+
+	if resolvedTarget == resolvedBase || !isWithin(resolvedBase, resolvedTarget) {
+		return fmt.Errorf(
+			"refusing to remove %s: not within base directory %s", target, base,
+		)
+	}
+
+	logrus.Infof("Removing %s", resolvedTarget)
+
+	if err := os.RemoveAll(resolvedTarget); err != nil {
+		return fmt.Errorf("remove %s: %w", resolvedTarget, err)
+	}
+
+	return nil
+}
+
+// SafeJoin joins the user supplied relative path rel to base and verifies
+// that the cleaned result stays within base. An error is returned if the
+// joined path escapes base, for example by using ".." path elements. Note
+// that symlinks are not resolved (see SafeRemoveAll for that).
+func SafeJoin(base, rel string) (string, error) {
+	joined := filepath.Join(base, rel)
+
+	if !isWithin(filepath.Clean(base), joined) {
+		return "", fmt.Errorf("path %q escapes base directory %q", rel, base)
+	}
+
+	return joined, nil
+}
+
+// isWithin returns true if path is base or located below base. Both paths
+// have to be cleaned.
+func isWithin(base, path string) bool {
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolvePath returns the absolute path with all symlinks evaluated.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.EvalSymlinks(abs)
+}
+
 // Exists indicates whether a file exists.
 func Exists(path string) bool {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -533,6 +730,318 @@ func IsDir(path string) bool {
 	return false
 }
 
+// FileHasContent returns true if the file at path has exactly the provided
+// data as content. The file sizes are compared first and the contents get
+// streamed afterwards, which avoids loading large files into memory. A
+// non-existing file results in false without an error.
+func FileHasContent(path string, data []byte) (bool, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("stat file %s: %w", path, err)
+	}
+
+	if !info.Mode().IsRegular() {
+		return false, fmt.Errorf("%s is not a regular file", path)
+	}
+
+	if info.Size() != int64(len(data)) {
+		return false, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("open file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	const chunkSize = 32 * 1024
+
+	buf := make([]byte, chunkSize)
+
+	for offset := 0; offset < len(data); {
+		n, err := io.ReadFull(file, buf[:min(chunkSize, len(data)-offset)])
+		if err != nil {
+			return false, fmt.Errorf("read file %s: %w", path, err)
+		}
+
+		if !bytes.Equal(buf[:n], data[offset:offset+n]) {
+			return false, nil
+		}
+
+		offset += n
+	}
+
+	return true, nil
+}
+
+// binaryCheckSize is the number of bytes inspected by IsBinaryFile, which
+// is the same as git uses.
+const binaryCheckSize = 8000
+
+// IsBinaryFile returns true if the file at path appears to be binary. Like
+// git, it considers a file to be binary if its first 8000 bytes contain a
+// NUL byte. Empty files are considered to be text.
+func IsBinaryFile(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("open file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	buf := make([]byte, binaryCheckSize)
+
+	n, err := io.ReadFull(file, buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return false, fmt.Errorf("read file %s: %w", path, err)
+	}
+
+	return isBinary(buf[:n]), nil
+}
+
+// isBinary returns true if the first binaryCheckSize bytes of data contain a
+// NUL byte.
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), binaryCheckSize)], 0) != -1
+}
+
+// UnifiedDiff returns the differences between a and b in the unified diff
+// format with three lines of context, while labelA and labelB are used as
+// file names in the header. An empty string is returned if a and b are equal.
+// If any of them is binary (see IsBinaryFile), only a "Binary files differ"
+// line is returned.
+func UnifiedDiff(a, b []byte, labelA, labelB string) string {
+	if bytes.Equal(a, b) {
+		return ""
+	}
+
+	if isBinary(a) || isBinary(b) {
+		return fmt.Sprintf("Binary files %s and %s differ\n", labelA, labelB)
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        diffLines(a),
+		B:        diffLines(b),
+		FromFile: labelA,
+		ToFile:   labelB,
+		Context:  3,
+	})
+	if err != nil {
+		// Writing into a string builder does not fail
+		logrus.Warnf("Unable to create diff: %v", err)
+	}
+
+	return diff
+}
+
+// diffLines splits data into lines including their line endings. A missing
+// newline at the end of the data is marked like diff does.
+func diffLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		lines[last] += "\n\\ No newline at end of file\n"
+	}
+
+	return lines
+}
+
+// CheckFilePermissions returns true if the permission bits of the file at path
+// are within maxPerm, which means that no permission bit is set which is not
+// also set in maxPerm. For example, a maxPerm of 0o600 ensures that a private
+// key is not accessible by group or others. Symlinks are followed.
+//
+// Windows has a limited permission model: the reported mode is always 0o666
+// or 0o444 (read-only), depending on the read-only attribute of the file, and
+// does not reflect any ACLs. Restrictive values of maxPerm like 0o600
+// therefore never match on Windows.
+func CheckFilePermissions(path string, maxPerm os.FileMode) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("stat file %s: %w", path, err)
+	}
+
+	return info.Mode().Perm()&^maxPerm.Perm() == 0, nil
+}
+
+// EnforceFilePermissions removes all permission bits of the file at path
+// which are not set in maxPerm, for example to reduce a world-readable
+// private key to 0o600. Files which are already within maxPerm stay
+// unchanged. An error is returned if the permissions cannot be reduced
+// accordingly.
+//
+// On Windows, changing the mode only toggles the read-only attribute of the
+// file (see CheckFilePermissions), which is why restrictive values of maxPerm
+// cannot be enforced and always result in an error there.
+func EnforceFilePermissions(path string, maxPerm os.FileMode) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat file %s: %w", path, err)
+	}
+
+	perm := info.Mode().Perm()
+	if perm&^maxPerm.Perm() == 0 {
+		return nil
+	}
+
+	logrus.Infof("Reducing permissions of %s from %#o to %#o", path, perm, perm&maxPerm.Perm())
+
+	if err := os.Chmod(path, perm&maxPerm.Perm()); err != nil {
+		return fmt.Errorf("change permissions of %s: %w", path, err)
+	}
+
+	ok, err := CheckFilePermissions(path, maxPerm)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("unable to reduce permissions of %s to %#o", path, maxPerm.Perm())
+	}
+
+	return nil
+}
+
+// ReadGzipFile reads and decompresses the gzipped file at path. An error is
+// returned if the decompressed size exceeds maxBytes, which protects against
+// decompression bombs.
+func ReadGzipFile(path string, maxBytes int64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("create gzip reader for %s: %w", path, err)
+	}
+	defer reader.Close()
+
+	// Read one byte more than allowed to detect exceeding content.
+	data, err := io.ReadAll(io.LimitReader(reader, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("decompress file %s: %w", path, err)
+	}
+
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf(
+			"decompressed size of %s exceeds the limit of %d bytes", path, maxBytes,
+		)
+	}
+
+	return data, nil
+}
+
+// transientFileErrors are the errors of file operations which are retried by
+// RetryFileOp.
+var transientFileErrors = []error{
+	syscall.EAGAIN,
+	syscall.EBUSY,
+	syscall.EINTR,
+	syscall.ETXTBSY,
+}
+
+const (
+	fileOpAttempts   = 5
+	fileOpRetryDelay = 50 * time.Millisecond
+)
+
+// RetryFileOp runs the file operation fn and retries it with an exponential
+// backoff as long as it fails with a transient error like EBUSY or ETXTBSY,
+// which occur for example on network filesystems. Permanent errors are
+// returned immediately, while the last transient error is returned once all
+// attempts failed.
+func RetryFileOp(fn func() error) error {
+	delay := fileOpRetryDelay
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !isTransientFileError(err) || attempt == fileOpAttempts {
+			return err
+		}
+
+		logrus.Debugf(
+			"Retrying file operation in %s (attempt %d/%d): %v",
+			delay, attempt, fileOpAttempts, err,
+		)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// isTransientFileError returns true if err is one of transientFileErrors.
+func isTransientFileError(err error) bool {
+	for _, transient := range transientFileErrors {
+		if errors.Is(err, transient) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsTerminal returns true if the provided writer is a terminal.
+func IsTerminal(w io.Writer) bool {
+	_, isTerminal := term.GetFdInfo(w)
+
+	return isTerminal
+}
+
+// StdinHasData returns true if the standard input is a pipe or redirected
+// from a file, like in "cmd < file" or "cat file | cmd". It returns false for
+// an interactive terminal, which allows tools to not hang waiting for input
+// which will never be provided.
+func StdinHasData() bool {
+	return hasData(os.Stdin)
+}
+
+// hasData returns true if the file is not a terminal but a pipe or a regular
+// file.
+func hasData(f *os.File) bool {
+	if _, isTerminal := term.GetFdInfo(f); isTerminal {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeNamedPipe != 0 || info.Mode().IsRegular()
+}
+
+// ReadStdin reads the standard input until EOF. An error is returned if the
+// data exceeds maxBytes. StdinHasData should be used before to not block on
+// an interactive terminal.
+func ReadStdin(maxBytes int64) ([]byte, error) {
+	return readLimited(os.Stdin, maxBytes)
+}
+
+// readLimited reads r until EOF and returns an error if the data exceeds
+// maxBytes.
+func readLimited(r io.Reader, maxBytes int64) ([]byte, error) {
+	// Read one byte more than allowed to detect exceeding content.
+	data, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read stdin: %w", err)
+	}
+
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("stdin exceeds the limit of %d bytes", maxBytes)
+	}
+
+	return data, nil
+}
+
 // WrapText wraps a text.
 func WrapText(originalText string, lineSize int) (wrappedText string) {
 	words := strings.Fields(strings.TrimSpace(originalText))
@@ -563,12 +1072,74 @@ func StripControlCharacters(logData []byte) []byte {
 // StripSensitiveData removes data deemed sensitive or non public
 // from a byte slice (ported from the original bash anago).
 func StripSensitiveData(logData []byte) []byte {
-	// Remove OAuth tokens
-	logData = regexpOauthToken.ReplaceAllLiteral(logData, []byte("__SANITIZED__:x-oauth-basic"))
-	// Remove GitHub tokens
-	logData = regexpGitToken.ReplaceAllLiteral(logData, []byte("//git:__SANITIZED__:@github.com"))
+	return redact.Data(logData)
+}
+
+// ParseEnvSlice converts an environment slice containing "key=value"
+// entries, like the one returned by os.Environ(), into a map. Values may
+// contain "=" characters, entries without any "=" get an empty value and
+// later entries overwrite earlier ones with the same key.
+func ParseEnvSlice(env []string) map[string]string {
+	res := make(map[string]string, len(env))
+
+	for _, entry := range env {
+		key, value, _ := strings.Cut(entry, "=")
+		if key == "" {
+			continue
+		}
+
+		res[key] = value
+	}
+
+	return res
+}
+
+// EnvMapToSlice converts an environment map into a slice of "key=value"
+// entries sorted by their keys. It is the inverse of ParseEnvSlice.
+func EnvMapToSlice(m map[string]string) []string {
+	res := make([]string, 0, len(m))
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		res = append(res, key+"="+m[key])
+	}
+
+	return res
+}
+
+// SortedEnv returns a copy of the environment slice containing "key=value"
+// entries sorted by their keys, which allows to log the environment in a
+// reproducible way. Contrary to EnvMapToSlice(ParseEnvSlice(env)), all
+// entries are kept, while entries with the same key stay in their order.
+func SortedEnv(env []string) []string {
+	res := slices.Clone(env)
+	slices.SortStableFunc(res, func(a, b string) int {
+		keyA, _, _ := strings.Cut(a, "=")
+		keyB, _, _ := strings.Cut(b, "=")
+
+		return strings.Compare(keyA, keyB)
+	})
 
-	return logData
+	return res
+}
+
+// Timed runs fn and logs the elapsed time together with the provided name of
+// the operation on info level. The error of fn is returned unchanged.
+func Timed(name string, fn func() error) error {
+	_, err := TimedValue(name, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+
+	return err
+}
+
+// TimedValue behaves like Timed but returns the value of fn in addition to
+// its error.
+func TimedValue[T any](name string, fn func() (T, error)) (T, error) {
+	start := time.Now()
+	res, err := fn()
+
+	logrus.Infof("%s took %s", name, time.Since(start).Round(time.Millisecond))
+
+	return res, err
 }
 
 // CleanLogFile cleans control characters and sensitive data from a file.
@@ -616,3 +1187,62 @@ func CleanLogFile(logPath string) (err error) {
 
 	return err
 }
+
+// CacheKey returns a deterministic hex-encoded SHA256 key for the contents of
+// the provided files and the extra strings, for example build parameters. The
+// file digests are sorted, which means that the order of the paths does not
+// matter, whereas the order of the extra strings does. Changing the content of
+// any file or any of the extra strings results in a different key.
+func CacheKey(paths []string, extra ...string) (string, error) {
+	digests := make([]string, 0, len(paths))
+
+	for _, path := range paths {
+		digest, err := hash.SHA256ForFile(path)
+		if err != nil {
+			return "", fmt.Errorf("hashing cache key input: %w", err)
+		}
+
+		digests = append(digests, digest)
+	}
+
+	slices.Sort(digests)
+
+	hasher := sha256.New()
+
+	// Prefix the number of files as well as the length of every field to
+	// avoid ambiguous concatenations, like the extra strings "ab" and "a", "b".
+	fmt.Fprintf(hasher, "%d\n", len(digests))
+
+	for _, field := range append(digests, extra...) {
+		fmt.Fprintf(hasher, "%d:%s\n", len(field), field)
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// ParseDuration parses the provided duration string, like "30s" or "2h45m",
+// and verifies that it is within the inclusive min and max bounds. A zero max
+// means that there is no upper bound. The returned error contains the invalid
+// value, which allows to wrap it with the name of the config field.
+func ParseDuration(s string, minDuration, maxDuration time.Duration) (time.Duration, error) {
+	if maxDuration > 0 && minDuration > maxDuration {
+		return 0, fmt.Errorf(
+			"invalid bounds: minimum %s is greater than maximum %s", minDuration, maxDuration,
+		)
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("parse duration: %w", err)
+	}
+
+	if d < minDuration {
+		return 0, fmt.Errorf("duration %s is less than the minimum of %s", d, minDuration)
+	}
+
+	if maxDuration > 0 && d > maxDuration {
+		return 0, fmt.Errorf("duration %s is greater than the maximum of %s", d, maxDuration)
+	}
+
+	return d, nil
+}
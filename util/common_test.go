@@ -17,13 +17,19 @@ limitations under the License.
 package util
 
 import (
+	"bytes"
+	"compress/gzip"
 	"errors"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/blang/semver/v4"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/require"
 )
 
@@ -605,3 +611,606 @@ func TestIsDir(t *testing.T) {
 		})
 	}
 }
+
+func TestFileHasContent(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.txt")
+	large := bytes.Repeat([]byte("0123456789"), 10000)
+	require.NoError(t, os.WriteFile(path, large, os.FileMode(0o644)))
+
+	changed := bytes.Clone(large)
+	changed[len(changed)-1] = 'x'
+
+	for _, tc := range []struct {
+		name        string
+		path        string
+		data        []byte
+		expected    bool
+		shouldError bool
+	}{
+		{name: "same content", path: path, data: large, expected: true},
+		{name: "different size", path: path, data: large[1:], expected: false},
+		{name: "different content", path: path, data: changed, expected: false},
+		{name: "nonexisting", path: filepath.Join(dir, "not-there.txt"), data: large, expected: false},
+		{name: "directory", path: dir, data: large, shouldError: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			res, err := FileHasContent(tc.path, tc.data)
+			if tc.shouldError {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, res)
+		})
+	}
+}
+
+func TestSafeRemoveAll(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name        string
+		prepare     func(t *testing.T, base string) (target, check string)
+		removed     bool
+		shouldError bool
+	}{
+		{
+			name: "file within base",
+			prepare: func(t *testing.T, base string) (string, string) {
+				t.Helper()
+				path := filepath.Join(base, "sub", "file.txt")
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), os.FileMode(0o755)))
+				require.NoError(t, os.WriteFile(path, []byte("Yo!"), os.FileMode(0o644)))
+
+				return path, path
+			},
+			removed: true,
+		},
+		{
+			name: "directory within base",
+			prepare: func(t *testing.T, base string) (string, string) {
+				t.Helper()
+				path := filepath.Join(base, "sub")
+				require.NoError(t, os.MkdirAll(filepath.Join(path, "deep"), os.FileMode(0o755)))
+
+				return path, path
+			},
+			removed: true,
+		},
+		{
+			name: "nonexisting",
+			prepare: func(t *testing.T, base string) (string, string) {
+				t.Helper()
+				path := filepath.Join(base, "not", "there")
+
+				return path, path
+			},
+			removed: true,
+		},
+		{
+			name: "base itself",
+			prepare: func(t *testing.T, base string) (string, string) {
+				t.Helper()
+
+				return base, base
+			},
+			shouldError: true,
+		},
+		{
+			name: "traversal",
+			prepare: func(t *testing.T, base string) (string, string) {
+				t.Helper()
+				outside := t.TempDir()
+
+				return filepath.Join(base, "..", filepath.Base(outside)), outside
+			},
+			shouldError: true,
+		},
+		{
+			name: "symlink escape",
+			prepare: func(t *testing.T, base string) (string, string) {
+				t.Helper()
+				outside := t.TempDir()
+				path := filepath.Join(outside, "file.txt")
+				require.NoError(t, os.WriteFile(path, []byte("Yo!"), os.FileMode(0o644)))
+				require.NoError(t, os.Symlink(outside, filepath.Join(base, "link")))
+
+				return filepath.Join(base, "link", "file.txt"), path
+			},
+			shouldError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			base := t.TempDir()
+			target, check := tc.prepare(t, base)
+
+			err := SafeRemoveAll(base, target)
+			if tc.shouldError {
+				require.Error(t, err)
+				require.True(t, Exists(check))
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.removed, !Exists(check))
+		})
+	}
+}
+
+func TestTimed(t *testing.T) {
+	hook := logtest.NewGlobal()
+	defer hook.Reset()
+
+	require.NoError(t, Timed("success", func() error { return nil }))
+	require.Contains(t, hook.LastEntry().Message, "success took ")
+
+	err := errors.New("test")
+	require.ErrorIs(t, Timed("failure", func() error { return err }), err)
+	require.Contains(t, hook.LastEntry().Message, "failure took ")
+}
+
+func TestTimedValue(t *testing.T) {
+	hook := logtest.NewGlobal()
+	defer hook.Reset()
+
+	res, err := TimedValue("value", func() (int, error) { return 42, nil })
+	require.NoError(t, err)
+	require.Equal(t, 42, res)
+	require.Contains(t, hook.LastEntry().Message, "value took ")
+}
+
+func TestParseEnvSlice(t *testing.T) {
+	t.Parallel()
+
+	res := ParseEnvSlice([]string{
+		"FOO=bar", "EMPTY=", "NOVALUE", "EQ=a=b=c", "FOO=baz", "=invalid",
+	})
+	require.Equal(t, map[string]string{
+		"FOO":     "baz",
+		"EMPTY":   "",
+		"NOVALUE": "",
+		"EQ":      "a=b=c",
+	}, res)
+}
+
+func TestEnvMapToSlice(t *testing.T) {
+	t.Parallel()
+
+	env := []string{"A=1", "B=", "C=x=y"}
+	require.Equal(t, env, EnvMapToSlice(ParseEnvSlice(env)))
+	require.Empty(t, EnvMapToSlice(nil))
+}
+
+func TestSortedEnv(t *testing.T) {
+	t.Parallel()
+
+	env := []string{"PATH=/bin", "B=2", "A_B=3", "A=1", "NOVALUE", "B=1"}
+	res := SortedEnv(env)
+	require.Equal(t, []string{"A=1", "A_B=3", "B=2", "B=1", "NOVALUE", "PATH=/bin"}, res)
+	require.Equal(t, "PATH=/bin", env[0])
+	require.Empty(t, SortedEnv(nil))
+}
+
+func TestIsTerminal(t *testing.T) {
+	t.Parallel()
+
+	require.False(t, IsTerminal(&bytes.Buffer{}))
+
+	f, err := os.CreateTemp(t.TempDir(), "file")
+	require.NoError(t, err)
+	defer f.Close()
+	require.False(t, IsTerminal(f))
+}
+
+func TestSafeJoin(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		base, rel   string
+		expected    string
+		shouldError bool
+	}{
+		{base: "/base", rel: "file.txt", expected: "/base/file.txt"},
+		{base: "/base/", rel: "sub/../file.txt", expected: "/base/file.txt"},
+		{base: "/base", rel: "", expected: "/base"},
+		{base: "/base", rel: "/abs/file.txt", expected: "/base/abs/file.txt"},
+		{base: "base", rel: "sub/file.txt", expected: "base/sub/file.txt"},
+		{base: "/base", rel: "../file.txt", shouldError: true},
+		{base: "/base", rel: "sub/../../file.txt", shouldError: true},
+		{base: "/base", rel: "../base-other/file.txt", shouldError: true},
+	} {
+		res, err := SafeJoin(tc.base, tc.rel)
+		if tc.shouldError {
+			require.Error(t, err, tc.rel)
+
+			continue
+		}
+
+		require.NoError(t, err)
+		require.Equal(t, filepath.FromSlash(tc.expected), res)
+	}
+}
+
+func TestUpdateSymlink(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	link := filepath.Join(dir, "current")
+
+	for _, version := range []string{"v1", "v2"} {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, version), os.FileMode(0o755)))
+		require.NoError(t, UpdateSymlink(version, link))
+
+		dest, err := os.Readlink(link)
+		require.NoError(t, err)
+		require.Equal(t, version, dest)
+	}
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, []byte("Yo!"), os.FileMode(0o644)))
+	require.Error(t, UpdateSymlink("v1", file))
+
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.Equal(t, "Yo!", string(content))
+}
+
+func TestParseRepoSpec(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		spec                 string
+		owner, repo, version string
+		shouldError          bool
+	}{
+		{spec: "kubernetes/release", owner: "kubernetes", repo: "release"},
+		{spec: "kubernetes/release@v1.2.3", owner: "kubernetes", repo: "release", version: "v1.2.3"},
+		{spec: "kubernetes/release@1.2.3-rc.1", owner: "kubernetes", repo: "release", version: "1.2.3-rc.1"},
+		{
+			spec:    "github.com/golangci/golangci-lint/cmd/golangci-lint@v1.63.4",
+			owner:   "golangci",
+			repo:    "golangci-lint",
+			version: "v1.63.4",
+		},
+		{spec: "github.com/kubernetes-sigs/zeitgeist", owner: "kubernetes-sigs", repo: "zeitgeist"},
+		{spec: "release", shouldError: true},
+		{spec: "github.com/kubernetes", shouldError: true},
+		{spec: "kubernetes/", shouldError: true},
+		{spec: "/release", shouldError: true},
+		{spec: "kubernetes/release@latest", shouldError: true},
+		{spec: "kubernetes/release@", shouldError: true},
+	} {
+		owner, repo, version, err := ParseRepoSpec(tc.spec)
+		if tc.shouldError {
+			require.Error(t, err, tc.spec)
+
+			continue
+		}
+
+		require.NoError(t, err, tc.spec)
+		require.Equal(t, tc.owner, owner)
+		require.Equal(t, tc.repo, repo)
+		require.Equal(t, tc.version, version)
+	}
+}
+
+func TestIsBinaryFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	for _, tc := range []struct {
+		name        string
+		content     []byte
+		expected    bool
+		shouldError bool
+	}{
+		{name: "text", content: []byte("Hello\nWorld\n")},
+		{name: "utf-8", content: []byte("Grüße 🚀\n")},
+		{name: "empty", content: []byte{}},
+		{name: "binary", content: []byte{0x7f, 'E', 'L', 'F', 0x02, 0x00}, expected: true},
+		{name: "late NUL", content: append(bytes.Repeat([]byte("a"), binaryCheckSize), 0)},
+		{name: "nonexisting", shouldError: true},
+	} {
+		path := filepath.Join(dir, tc.name)
+		if tc.content != nil {
+			require.NoError(t, os.WriteFile(path, tc.content, os.FileMode(0o644)))
+		}
+
+		res, err := IsBinaryFile(path)
+		if tc.shouldError {
+			require.Error(t, err, tc.name)
+
+			continue
+		}
+
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.expected, res, tc.name)
+	}
+
+	_, err := IsBinaryFile(dir)
+	require.Error(t, err)
+}
+
+func TestFilePermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix permission bits are not supported on windows")
+	}
+
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(path, []byte("secret"), os.FileMode(0o600)))
+	require.NoError(t, os.Chmod(path, 0o644))
+
+	ok, err := CheckFilePermissions(path, 0o644)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = CheckFilePermissions(path, 0o600)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, EnforceFilePermissions(path, 0o600))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// Already within the allowed bits
+	require.NoError(t, EnforceFilePermissions(path, 0o700))
+
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	_, err = CheckFilePermissions(filepath.Join(t.TempDir(), "missing"), 0o600)
+	require.Error(t, err)
+	require.Error(t, EnforceFilePermissions(filepath.Join(t.TempDir(), "missing"), 0o600))
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		a, b     []byte
+		expected string
+	}{
+		{
+			name: "equal",
+			a:    []byte("one\ntwo\n"),
+			b:    []byte("one\ntwo\n"),
+		},
+		{
+			name:     "changed line",
+			a:        []byte("one\ntwo\nthree\n"),
+			b:        []byte("one\n2\nthree\n"),
+			expected: "--- a/file\n+++ b/file\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n",
+		},
+		{
+			name:     "added lines",
+			a:        []byte{},
+			b:        []byte("one\ntwo\n"),
+			expected: "--- a/file\n+++ b/file\n@@ -0,0 +1,2 @@\n+one\n+two\n",
+		},
+		{
+			name:     "missing newline at end of file",
+			a:        []byte("one\ntwo\n"),
+			b:        []byte("one\ntwo"),
+			expected: "--- a/file\n+++ b/file\n@@ -1,2 +1,2 @@\n one\n-two\n+two\n\\ No newline at end of file\n",
+		},
+		{
+			name:     "binary",
+			a:        []byte("text\n"),
+			b:        []byte{0x7f, 'E', 'L', 'F', 0x00},
+			expected: "Binary files a/file and b/file differ\n",
+		},
+	} {
+		require.Equal(t, tc.expected, UnifiedDiff(tc.a, tc.b, "a/file", "b/file"), tc.name)
+	}
+}
+
+func TestReadGzipFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	content := bytes.Repeat([]byte("a"), 1000)
+
+	var buf bytes.Buffer
+
+	writer := gzip.NewWriter(&buf)
+	_, err := writer.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	path := filepath.Join(dir, "file.gz")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), os.FileMode(0o644)))
+
+	res, err := ReadGzipFile(path, 1000)
+	require.NoError(t, err)
+	require.Equal(t, content, res)
+
+	_, err = ReadGzipFile(path, 999)
+	require.Error(t, err)
+
+	plain := filepath.Join(dir, "plain")
+	require.NoError(t, os.WriteFile(plain, content, os.FileMode(0o644)))
+
+	_, err = ReadGzipFile(plain, 1000)
+	require.Error(t, err)
+
+	_, err = ReadGzipFile(filepath.Join(dir, "missing"), 1000)
+	require.Error(t, err)
+}
+
+func TestRetryFileOp(t *testing.T) {
+	t.Parallel()
+
+	var calls int
+
+	err := RetryFileOp(func() error {
+		calls++
+		if calls < 3 {
+			return &os.PathError{Op: "rename", Path: "file", Err: syscall.EBUSY}
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+
+	calls = 0
+	err = RetryFileOp(func() error {
+		calls++
+
+		return &os.PathError{Op: "remove", Path: "file", Err: syscall.ENOENT}
+	})
+	require.ErrorIs(t, err, syscall.ENOENT)
+	require.Equal(t, 1, calls)
+
+	calls = 0
+	err = RetryFileOp(func() error {
+		calls++
+
+		return syscall.ETXTBSY
+	})
+	require.ErrorIs(t, err, syscall.ETXTBSY)
+	require.Equal(t, fileOpAttempts, calls)
+}
+
+func TestSortTags(t *testing.T) {
+	t.Parallel()
+
+	sorted, err := SortTags([]string{
+		"v1.10.0", "v1.2.0", "1.2.0", "v1.2.0-rc.1", "v0.9.1", "v1.10.0",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"v0.9.1", "v1.2.0-rc.1", "v1.2.0", "v1.10.0"}, sorted)
+
+	sorted, err = SortTags(nil)
+	require.NoError(t, err)
+	require.Empty(t, sorted)
+
+	_, err = SortTags([]string{"v1.0.0", "latest", "v1.x"})
+	require.ErrorContains(t, err, "latest, v1.x")
+}
+
+func TestCacheKey(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a")
+	b := filepath.Join(dir, "b")
+
+	require.NoError(t, os.WriteFile(a, []byte("a"), 0o600))
+	require.NoError(t, os.WriteFile(b, []byte("b"), 0o600))
+
+	key, err := CacheKey([]string{a, b}, "amd64", "linux")
+	require.NoError(t, err)
+	require.Len(t, key, 64)
+
+	// The order of the paths does not matter
+	other, err := CacheKey([]string{b, a}, "amd64", "linux")
+	require.NoError(t, err)
+	require.Equal(t, key, other)
+
+	// Changing a parameter changes the key
+	other, err = CacheKey([]string{a, b}, "arm64", "linux")
+	require.NoError(t, err)
+	require.NotEqual(t, key, other)
+
+	other, err = CacheKey([]string{a, b}, "amd64linux")
+	require.NoError(t, err)
+	require.NotEqual(t, key, other)
+
+	// Changing a file changes the key
+	require.NoError(t, os.WriteFile(b, []byte("c"), 0o600))
+	other, err = CacheKey([]string{a, b}, "amd64", "linux")
+	require.NoError(t, err)
+	require.NotEqual(t, key, other)
+
+	_, err = CacheKey([]string{filepath.Join(dir, "missing")})
+	require.Error(t, err)
+}
+
+func TestParseDuration(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		input    string
+		min, max time.Duration
+		expected time.Duration
+		errMsg   string
+	}{
+		{input: "30s", min: time.Second, max: time.Hour, expected: 30 * time.Second},
+		{input: " 2h ", max: 2 * time.Hour, expected: 2 * time.Hour},
+		{input: "1s", min: time.Second, max: time.Second, expected: time.Second},
+		{input: "100h", min: time.Minute, expected: 100 * time.Hour},
+		{input: "500ms", min: time.Second, max: time.Hour, errMsg: "duration 500ms is less than the minimum of 1s"},
+		{input: "2h", max: time.Hour, errMsg: "duration 2h0m0s is greater than the maximum of 1h0m0s"},
+		{input: "-1s", errMsg: "less than the minimum"},
+		{input: "5", errMsg: "parse duration"},
+		{input: "", errMsg: "parse duration"},
+		{input: "1m", min: time.Hour, max: time.Minute, errMsg: "invalid bounds"},
+	} {
+		res, err := ParseDuration(tc.input, tc.min, tc.max)
+		if tc.errMsg != "" {
+			require.ErrorContains(t, err, tc.errMsg, tc.input)
+
+			continue
+		}
+
+		require.NoError(t, err, tc.input)
+		require.Equal(t, tc.expected, res, tc.input)
+	}
+}
+
+func TestHasData(t *testing.T) {
+	t.Parallel()
+
+	reader, writer, err := os.Pipe()
+	require.NoError(t, err)
+
+	defer reader.Close()
+	defer writer.Close()
+
+	require.True(t, hasData(reader))
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "file"))
+	require.NoError(t, err)
+
+	require.True(t, hasData(file))
+
+	// Closed files cannot be inspected
+	require.NoError(t, file.Close())
+	require.False(t, hasData(file))
+}
+
+func TestReadLimited(t *testing.T) {
+	t.Parallel()
+
+	data, err := readLimited(strings.NewReader("data"), 4)
+	require.NoError(t, err)
+	require.Equal(t, "data", string(data))
+
+	data, err = readLimited(strings.NewReader(""), 4)
+	require.NoError(t, err)
+	require.Empty(t, data)
+
+	_, err = readLimited(strings.NewReader("too much"), 4)
+	require.ErrorContains(t, err, "exceeds the limit of 4 bytes")
+}
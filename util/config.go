@@ -0,0 +1,103 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"sigs.k8s.io/release-utils/env"
+)
+
+// LoadConfig reads the JSON or YAML configuration file at path and unmarshals
+// it into v. Environment variable references like $VAR or ${VAR} are expanded
+// before parsing, where unset variables result in empty strings.
+//
+// The format is detected by the file extension (.json, .yaml or .yml) and
+// falls back to inspecting the content: everything starting with '{' or '['
+// is treated as JSON, all the rest as YAML.
+func LoadConfig(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file %s: %w", path, err)
+	}
+
+	data = []byte(os.Expand(string(data), func(key string) string {
+		return env.Default(key, "")
+	}))
+
+	if isJSONConfig(path, data) {
+		if err := json.Unmarshal(data, v); err != nil {
+			return fmt.Errorf("parse JSON config %s: %w", path, jsonErrorLocation(data, err))
+		}
+
+		return nil
+	}
+
+	if err := yaml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parse YAML config %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// isJSONConfig returns true if the config should be parsed as JSON.
+func isJSONConfig(path string, data []byte) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return true
+	case ".yaml", ".yml":
+		return false
+	}
+
+	trimmed := bytes.TrimSpace(data)
+
+	return bytes.HasPrefix(trimmed, []byte("{")) || bytes.HasPrefix(trimmed, []byte("["))
+}
+
+// jsonErrorLocation adds the line and column to JSON errors which provide
+// an offset.
+func jsonErrorLocation(data []byte, err error) error {
+	var offset int64
+
+	syntaxErr := &json.SyntaxError{}
+	typeErr := &json.UnmarshalTypeError{}
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return err
+	}
+
+	// The offset points behind the last read byte, which is the offending one
+	offset = min(offset, int64(len(data)))
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := max(len(before)-bytes.LastIndexByte(before, '\n')-1, 1)
+
+	return fmt.Errorf("line %d, column %d: %w", line, column, err)
+}
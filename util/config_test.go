@@ -0,0 +1,104 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("CONFIG_NAME", "test")
+	t.Setenv("CONFIG_COUNT", "3")
+
+	type config struct {
+		Name  string `json:"name"  yaml:"name"`
+		Count int    `json:"count" yaml:"count"`
+		Empty string `json:"empty" yaml:"empty"`
+	}
+
+	for _, tc := range []struct {
+		name, fileName, content string
+		expected                *config
+		errContains             string
+	}{
+		{
+			name:     "json by extension",
+			fileName: "config.json",
+			content:  `{"name": "$CONFIG_NAME", "count": ${CONFIG_COUNT}, "empty": "$CONFIG_UNSET"}`,
+			expected: &config{Name: "test", Count: 3},
+		},
+		{
+			name:     "yaml by extension",
+			fileName: "config.yaml",
+			content:  "name: ${CONFIG_NAME}\ncount: $CONFIG_COUNT\n",
+			expected: &config{Name: "test", Count: 3},
+		},
+		{
+			name:     "json by content",
+			fileName: "config",
+			content:  "  \n{\"name\": \"$CONFIG_NAME\"}",
+			expected: &config{Name: "test"},
+		},
+		{
+			name:     "yaml by content",
+			fileName: "config",
+			content:  "name: $CONFIG_NAME",
+			expected: &config{Name: "test"},
+		},
+		{
+			name:        "json syntax error",
+			fileName:    "config.json",
+			content:     "{\n  \"name\": \"test\",\n  \"count\": ,\n}",
+			errContains: "line 3, column 12",
+		},
+		{
+			name:        "json type error",
+			fileName:    "config.json",
+			content:     "{\n  \"count\": \"wrong\"\n}",
+			errContains: "line 2",
+		},
+		{
+			name:        "yaml error",
+			fileName:    "config.yml",
+			content:     "name: test\ncount: wrong\n",
+			errContains: "line 2",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.fileName)
+			require.NoError(t, os.WriteFile(path, []byte(tc.content), os.FileMode(0o644)))
+
+			res := &config{}
+			err := LoadConfig(path, res)
+
+			if tc.errContains != "" {
+				require.ErrorContains(t, err, tc.errContains)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, res)
+		})
+	}
+
+	require.Error(t, LoadConfig(filepath.Join(t.TempDir(), "not-there.json"), &config{}))
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Supported output formats of WriteStructured.
+const (
+	OutputFormatTable = "table"
+	OutputFormatJSON  = "json"
+	OutputFormatYAML  = "yaml"
+	OutputFormatCSV   = "csv"
+)
+
+// WriteStructured writes the headers and rows to w by using the provided
+// format, which has to be one of "table", "json", "yaml" or "csv". JSON and
+// YAML render every row as object, where the headers are used as keys in
+// their original order. Every row is required to have as many cells as there
+// are headers.
+func WriteStructured(w io.Writer, format string, headers []string, rows [][]string) error {
+	for i, row := range rows {
+		if len(row) != len(headers) {
+			return fmt.Errorf(
+				"row %d has %d cells but %d headers are defined", i, len(row), len(headers),
+			)
+		}
+	}
+
+	switch format {
+	case OutputFormatTable:
+		return writeTable(w, headers, rows)
+	case OutputFormatJSON:
+		return writeJSON(w, headers, rows)
+	case OutputFormatYAML:
+		return writeYAML(w, headers, rows)
+	case OutputFormatCSV:
+		return writeCSV(w, headers, rows)
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// writeTable writes the headers and rows as aligned plain text table.
+func writeTable(w io.Writer, headers []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	for _, row := range append([][]string{headers}, rows...) {
+		if _, err := fmt.Fprintln(tw, strings.Join(row, "\t")); err != nil {
+			return fmt.Errorf("write table row: %w", err)
+		}
+	}
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("flush table: %w", err)
+	}
+
+	return nil
+}
+
+// writeJSON writes the rows as indented JSON array of objects.
+func writeJSON(w io.Writer, headers []string, rows [][]string) error {
+	objects := make([]json.RawMessage, 0, len(rows))
+
+	for _, row := range rows {
+		var buf bytes.Buffer
+
+		buf.WriteByte('{')
+
+		for i, header := range headers {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			key, err := json.Marshal(header)
+			if err != nil {
+				return fmt.Errorf("marshal JSON key: %w", err)
+			}
+
+			value, err := json.Marshal(row[i])
+			if err != nil {
+				return fmt.Errorf("marshal JSON value: %w", err)
+			}
+
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(value)
+		}
+
+		buf.WriteByte('}')
+		objects = append(objects, buf.Bytes())
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(objects); err != nil {
+		return fmt.Errorf("encode JSON: %w", err)
+	}
+
+	return nil
+}
+
+// writeYAML writes the rows as YAML sequence of mappings.
+func writeYAML(w io.Writer, headers []string, rows [][]string) error {
+	seq := &yaml.Node{Kind: yaml.SequenceNode}
+
+	for _, row := range rows {
+		mapping := &yaml.Node{Kind: yaml.MappingNode}
+
+		for i, header := range headers {
+			mapping.Content = append(mapping.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: header},
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: row[i]},
+			)
+		}
+
+		seq.Content = append(seq.Content, mapping)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(seq); err != nil {
+		return fmt.Errorf("encode YAML: %w", err)
+	}
+
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("close YAML encoder: %w", err)
+	}
+
+	return nil
+}
+
+// writeCSV writes the headers and rows as CSV.
+func writeCSV(w io.Writer, headers []string, rows [][]string) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.WriteAll(append([][]string{headers}, rows...)); err != nil {
+		return fmt.Errorf("write CSV: %w", err)
+	}
+
+	return nil
+}
+
+// WriteKeyValue writes the pairs as two aligned columns of keys and values to
+// w, preserving their order. Keys are right-padded to the width of the
+// longest key, while continuation lines of multi-line values are indented to
+// the value column.
+func WriteKeyValue(w io.Writer, pairs [][2]string) error {
+	width := 0
+	for _, pair := range pairs {
+		width = max(width, utf8.RuneCountInString(pair[0]))
+	}
+
+	indent := strings.Repeat(" ", width+2)
+
+	for _, pair := range pairs {
+		key := pair[0] + strings.Repeat(" ", width-utf8.RuneCountInString(pair[0]))
+		value := strings.ReplaceAll(pair[1], "\n", "\n"+indent)
+
+		if _, err := fmt.Fprintln(w, strings.TrimRight(key+"  "+value, " ")); err != nil {
+			return fmt.Errorf("write key value pair: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteStructured(t *testing.T) {
+	t.Parallel()
+
+	headers := []string{"name", "version"}
+	rows := [][]string{{"kubectl", "v1.32.0"}, {"kube-apiserver", "1.0"}}
+
+	for _, tc := range []struct {
+		format   string
+		expected string
+	}{
+		{
+			format: OutputFormatTable,
+			expected: "name            version\n" +
+				"kubectl         v1.32.0\n" +
+				"kube-apiserver  1.0\n",
+		},
+		{
+			format: OutputFormatJSON,
+			expected: "[\n" +
+				"  {\n    \"name\": \"kubectl\",\n    \"version\": \"v1.32.0\"\n  },\n" +
+				"  {\n    \"name\": \"kube-apiserver\",\n    \"version\": \"1.0\"\n  }\n" +
+				"]\n",
+		},
+		{
+			format: OutputFormatYAML,
+			expected: "- name: kubectl\n  version: v1.32.0\n" +
+				"- name: kube-apiserver\n  version: \"1.0\"\n",
+		},
+		{
+			format:   OutputFormatCSV,
+			expected: "name,version\nkubectl,v1.32.0\nkube-apiserver,1.0\n",
+		},
+	} {
+		var buf bytes.Buffer
+		require.NoError(t, WriteStructured(&buf, tc.format, headers, rows), tc.format)
+		require.Equal(t, tc.expected, buf.String(), tc.format)
+	}
+}
+
+func TestWriteStructuredFailure(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.Error(t, WriteStructured(&buf, "xml", []string{"a"}, nil))
+	require.Error(t, WriteStructured(&buf, OutputFormatJSON, []string{"a"}, [][]string{{"1", "2"}}))
+	require.Empty(t, buf.String())
+}
+
+func TestWriteKeyValue(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteKeyValue(&buf, [][2]string{
+		{"Name", "kubectl"},
+		{"Version", "v1.32.0"},
+		{"Empty", ""},
+		{"Description", "line one\nline two"},
+	}))
+	require.Equal(t,
+		"Name         kubectl\n"+
+			"Version      v1.32.0\n"+
+			"Empty\n"+
+			"Description  line one\n"+
+			"             line two\n",
+		buf.String(),
+	)
+
+	buf.Reset()
+	require.NoError(t, WriteKeyValue(&buf, nil))
+	require.Empty(t, buf.String())
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+const progressBarWidth = 40
+
+var spinnerFrames = []string{"|", "/", "-", `\`}
+
+// ProgressBar renders a single line progress bar to a terminal.
+type ProgressBar struct {
+	mu         sync.Mutex
+	w          io.Writer
+	isTerminal bool
+	total      int64
+	current    int64
+	frame      int
+	finished   bool
+}
+
+// NewProgressBar creates a new progress bar writing to w. The bar is only
+// drawn if w is a terminal, otherwise nothing will be written at all. A
+// total of zero or less results in a spinner, which can be used if the total
+// amount is unknown.
+func NewProgressBar(w io.Writer, total int64) *ProgressBar {
+	return &ProgressBar{
+		w:          w,
+		isTerminal: IsTerminal(w),
+		total:      total,
+	}
+}
+
+// Add increments the progress by n and redraws the bar. It is safe to be
+// called concurrently.
+func (p *ProgressBar) Add(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.finished {
+		return
+	}
+
+	p.current += n
+	p.frame++
+	p.draw()
+}
+
+// Finish draws the final state of the bar and terminates its line.
+// Subsequent calls to Add or Finish are ignored.
+func (p *ProgressBar) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.finished {
+		return
+	}
+
+	p.finished = true
+	p.draw()
+
+	if p.isTerminal {
+		fmt.Fprintln(p.w)
+	}
+}
+
+// draw renders the current state of the bar. The caller has to hold the lock.
+func (p *ProgressBar) draw() {
+	if !p.isTerminal {
+		return
+	}
+
+	if p.total <= 0 {
+		frame := spinnerFrames[p.frame%len(spinnerFrames)]
+		if p.finished {
+			frame = " "
+		}
+
+		fmt.Fprintf(p.w, "\r%s %s", frame, formatBytes(p.current))
+
+		return
+	}
+
+	current := min(p.current, p.total)
+	filled := int(current * progressBarWidth / p.total)
+
+	fmt.Fprintf(p.w, "\r[%s%s] %3d%% (%s/%s)",
+		strings.Repeat("=", filled),
+		strings.Repeat(" ", progressBarWidth-filled),
+		current*100/p.total,
+		formatBytes(current),
+		formatBytes(p.total),
+	)
+}
+
+// formatBytes returns a human readable representation of a byte count.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for i := n / unit; i >= unit; i /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressBarNoTerminal(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	bar := NewProgressBar(buf, 100)
+	bar.Add(50)
+	bar.Finish()
+	require.Empty(t, buf.String())
+}
+
+func TestProgressBar(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	bar := NewProgressBar(buf, 2048)
+	bar.isTerminal = true
+
+	bar.Add(1024)
+	require.Equal(t, "\r["+strings.Repeat("=", 20)+strings.Repeat(" ", 20)+"]  50% (1.0 KiB/2.0 KiB)", buf.String())
+
+	buf.Reset()
+	bar.Add(4096)
+	bar.Finish()
+	require.Equal(t,
+		"\r["+strings.Repeat("=", 40)+"] 100% (2.0 KiB/2.0 KiB)"+
+			"\r["+strings.Repeat("=", 40)+"] 100% (2.0 KiB/2.0 KiB)\n",
+		buf.String(),
+	)
+
+	buf.Reset()
+	bar.Add(1)
+	bar.Finish()
+	require.Empty(t, buf.String())
+}
+
+func TestProgressBarSpinner(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	bar := NewProgressBar(buf, 0)
+	bar.isTerminal = true
+
+	bar.Add(10)
+	bar.Add(3 * 1024 * 1024)
+	bar.Finish()
+	require.Equal(t, "\r/ 10 B\r- 3.0 MiB\r  3.0 MiB\n", buf.String())
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import "slices"
+
+// Difference returns all elements of a which are not part of b. The order of
+// a is preserved and duplicates are removed.
+func Difference[T comparable](a, b []T) []T {
+	exclude := toSet(b)
+
+	return filterUnique(a, func(elem T) bool {
+		_, ok := exclude[elem]
+
+		return !ok
+	})
+}
+
+// Intersection returns all elements of a which are also part of b. The order
+// of a is preserved and duplicates are removed.
+func Intersection[T comparable](a, b []T) []T {
+	include := toSet(b)
+
+	return filterUnique(a, func(elem T) bool {
+		_, ok := include[elem]
+
+		return ok
+	})
+}
+
+// Union returns all elements of a followed by the ones of b which are not
+// part of a. The order of the inputs is preserved and duplicates are removed.
+func Union[T comparable](a, b []T) []T {
+	return filterUnique(slices.Concat(a, b), func(T) bool { return true })
+}
+
+// toSet converts the slice into a set.
+func toSet[T comparable](s []T) map[T]struct{} {
+	set := make(map[T]struct{}, len(s))
+	for _, elem := range s {
+		set[elem] = struct{}{}
+	}
+
+	return set
+}
+
+// filterUnique returns the first occurrence of all elements of s for which
+// keep returns true.
+func filterUnique[T comparable](s []T, keep func(T) bool) []T {
+	res := []T{}
+	seen := make(map[T]struct{}, len(s))
+
+	for _, elem := range s {
+		if _, ok := seen[elem]; ok {
+			continue
+		}
+
+		seen[elem] = struct{}{}
+
+		if keep(elem) {
+			res = append(res, elem)
+		}
+	}
+
+	return res
+}
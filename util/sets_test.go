@@ -0,0 +1,49 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDifference(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, []string{"c", "a"}, Difference([]string{"c", "b", "a", "c"}, []string{"b", "d"}))
+	require.Equal(t, []string{}, Difference([]string{"a"}, []string{"a"}))
+	require.Equal(t, []string{"a"}, Difference([]string{"a"}, nil))
+	require.Equal(t, []int{}, Difference(nil, []int{1}))
+}
+
+func TestIntersection(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, []string{"c", "b"}, Intersection([]string{"c", "b", "a", "c", "b"}, []string{"b", "c", "d"}))
+	require.Equal(t, []string{}, Intersection([]string{"a"}, []string{"b"}))
+	require.Equal(t, []int{}, Intersection([]int{1}, nil))
+}
+
+func TestUnion(t *testing.T) {
+	t.Parallel()
+
+	a := []string{"c", "a", "c"}
+	require.Equal(t, []string{"c", "a", "b", "d"}, Union(a, []string{"b", "a", "d", "b"}))
+	require.Equal(t, []string{"c", "a", "c"}, a)
+	require.Equal(t, []int{}, Union[int](nil, nil))
+}
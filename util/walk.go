@@ -0,0 +1,131 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// WalkFiltered walks the file tree rooted at root and calls fn for each file
+// or directory which matches the provided include and exclude patterns.
+//
+// The patterns support doublestar globs (like "**/*.go") and are matched
+// against the slash separated path relative to root. An empty include list
+// matches every path, while exclude patterns always take precedence over
+// include patterns. Directories matching an exclude pattern are skipped
+// entirely.
+func WalkFiltered(
+	root string, include, exclude []string,
+	fn func(path string, info os.FileInfo) error,
+) error {
+	for _, patterns := range [][]string{include, exclude} {
+		for _, pattern := range patterns {
+			if !doublestar.ValidatePattern(pattern) {
+				return fmt.Errorf("invalid glob pattern: %q", pattern)
+			}
+		}
+	}
+
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return fmt.Errorf("get relative path of %s: %w", path, err)
+		}
+
+		if rel == "." {
+			return nil
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		if matchAny(exclude, rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if len(include) > 0 && !matchAny(include, rel) {
+			return nil
+		}
+
+		return fn(path, info)
+	})
+}
+
+// ErrCollectLimitExceeded is returned by CollectFiles if the file tree
+// exceeds the provided limits.
+var ErrCollectLimitExceeded = errors.New("file collection limit exceeded")
+
+// CollectFiles walks the file tree rooted at root and returns the paths of all
+// regular files in lexical order. Walking stops with ErrCollectLimitExceeded
+// as soon as more than maxFiles files or more than maxTotalBytes bytes in
+// total are found, which prevents runaway memory usage on unexpectedly huge
+// trees. Non-positive limits are not enforced. Symlinks are not followed.
+func CollectFiles(root string, maxFiles int, maxTotalBytes int64) ([]string, error) {
+	files := []string{}
+
+	var totalBytes int64
+
+	if err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		files = append(files, path)
+		totalBytes += info.Size()
+
+		if maxFiles > 0 && len(files) > maxFiles {
+			return fmt.Errorf("%w: more than %d files", ErrCollectLimitExceeded, maxFiles)
+		}
+
+		if maxTotalBytes > 0 && totalBytes > maxTotalBytes {
+			return fmt.Errorf("%w: more than %d bytes", ErrCollectLimitExceeded, maxTotalBytes)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("collect files in %s: %w", root, err)
+	}
+
+	return files, nil
+}
+
+// matchAny returns true if the path matches any of the glob patterns.
+func matchAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if doublestar.MatchUnvalidated(pattern, path) {
+			return true
+		}
+	}
+
+	return false
+}
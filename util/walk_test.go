@@ -0,0 +1,125 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalkFiltered(t *testing.T) {
+	baseTmpDir := t.TempDir()
+
+	for _, fileName := range []string{
+		"1.txt", "2.md", "sub/3.txt", "sub/4.md", "sub/deep/5.txt", "vendor/6.txt",
+	} {
+		path := filepath.Join(baseTmpDir, fileName)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), os.FileMode(0o755)))
+		require.NoError(t, os.WriteFile(path, []byte{1, 2, 3}, os.FileMode(0o644)))
+	}
+
+	for _, tc := range []struct {
+		include, exclude []string
+		expected         []string
+		shouldError      bool
+	}{
+		{ // all files
+			expected: []string{
+				"1.txt", "2.md", "sub", "sub/3.txt", "sub/4.md", "sub/deep",
+				"sub/deep/5.txt", "vendor", "vendor/6.txt",
+			},
+		},
+		{ // include only
+			include:  []string{"**/*.txt"},
+			expected: []string{"1.txt", "sub/3.txt", "sub/deep/5.txt", "vendor/6.txt"},
+		},
+		{ // exclude takes precedence
+			include:  []string{"**/*.txt"},
+			exclude:  []string{"vendor", "sub/deep/*"},
+			expected: []string{"1.txt", "sub/3.txt"},
+		},
+		{ // invalid pattern
+			include:     []string{"[a"},
+			shouldError: true,
+		},
+	} {
+		res := []string{}
+		err := WalkFiltered(baseTmpDir, tc.include, tc.exclude,
+			func(path string, _ os.FileInfo) error {
+				rel, err := filepath.Rel(baseTmpDir, path)
+				require.NoError(t, err)
+
+				res = append(res, filepath.ToSlash(rel))
+
+				return nil
+			},
+		)
+
+		if tc.shouldError {
+			require.Error(t, err)
+		} else {
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, res)
+		}
+	}
+}
+
+func TestCollectFiles(t *testing.T) {
+	baseTmpDir := t.TempDir()
+
+	for _, fileName := range []string{"1.txt", "sub/2.txt", "sub/deep/3.txt"} {
+		path := filepath.Join(baseTmpDir, fileName)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), os.FileMode(0o755)))
+		require.NoError(t, os.WriteFile(path, []byte{1, 2, 3}, os.FileMode(0o644)))
+	}
+
+	require.NoError(t, os.Symlink("1.txt", filepath.Join(baseTmpDir, "link")))
+
+	expected := []string{
+		filepath.Join(baseTmpDir, "1.txt"),
+		filepath.Join(baseTmpDir, "sub", "2.txt"),
+		filepath.Join(baseTmpDir, "sub", "deep", "3.txt"),
+	}
+
+	for _, tc := range []struct {
+		maxFiles      int
+		maxTotalBytes int64
+		shouldError   bool
+	}{
+		{},                              // no limits
+		{maxFiles: 3, maxTotalBytes: 9}, // exact limits
+		{maxFiles: 2, shouldError: true},
+		{maxTotalBytes: 8, shouldError: true},
+	} {
+		res, err := CollectFiles(baseTmpDir, tc.maxFiles, tc.maxTotalBytes)
+		if tc.shouldError {
+			require.ErrorIs(t, err, ErrCollectLimitExceeded)
+			require.Nil(t, res)
+
+			continue
+		}
+
+		require.NoError(t, err)
+		require.Equal(t, expected, res)
+	}
+
+	_, err := CollectFiles(filepath.Join(baseTmpDir, "missing"), 0, 0)
+	require.Error(t, err)
+}
@@ -29,7 +29,7 @@ import (
 //
 // ```.
 func Version() *cobra.Command {
-	return version("")
+	return version("", nil)
 }
 
 // WithFont returns a cobra command to be added to another cobra command with a select font for ASCII, like:
@@ -39,10 +39,24 @@ func Version() *cobra.Command {
 //
 // ```.
 func WithFont(fontName string) *cobra.Command {
-	return version(fontName)
+	return version(fontName, nil)
 }
 
-func version(fontName string) *cobra.Command {
+// WithDependencies returns a cobra command to be added to another cobra
+// command, which additionally prints the versions of the provided
+// dependencies, like:
+// ```go
+//
+//	rootCmd.AddCommand(version.WithDependencies(map[string]string{
+//		"golangci-lint": "v1.63.4",
+//	}))
+//
+// ```.
+func WithDependencies(dependencies map[string]string) *cobra.Command {
+	return version("", dependencies)
+}
+
+func version(fontName string, dependencies map[string]string) *cobra.Command {
 	var outputJSON bool
 
 	cmd := &cobra.Command{
@@ -52,6 +66,7 @@ func version(fontName string) *cobra.Command {
 			v := GetVersionInfo()
 			v.Name = cmd.Root().Name()
 			v.Description = cmd.Root().Short
+			v.Dependencies = dependencies
 
 			v.FontName = ""
 			if fontName != "" && v.CheckFontName(fontName) {
@@ -19,15 +19,20 @@ package version
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"runtime"
 	"runtime/debug"
+	"slices"
 	"strings"
 	"sync"
 	"text/tabwriter"
 	"time"
 
 	"github.com/common-nighthawk/go-figure"
+	"github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/release-utils/command"
 )
 
 const unknown = "unknown"
@@ -68,6 +73,11 @@ type Info struct {
 	Compiler     string `json:"compiler"`
 	Platform     string `json:"platform"`
 
+	// Dependencies are optional versions of tools or libraries the binary
+	// relies on, keyed by their name. They can be populated by downstream
+	// programs to ease debugging version mismatches.
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+
 	ASCIIName   string `json:"-"`
 	FontName    string `json:"-"`
 	Name        string `json:"-"`
@@ -183,6 +193,37 @@ func GetVersionInfo() Info {
 	return info
 }
 
+// DescribeVersion returns a human-friendly version of the current git
+// checkout, like "v1.2.3-5-gabc123-dirty", by running
+// `git describe --tags --always --dirty`. It falls back to the embedded
+// version if git is not available or the working directory is not a git
+// repository.
+func DescribeVersion() (string, error) {
+	fallback := GetVersionInfo().GitVersion
+
+	if _, ok := command.LookPath("git"); !ok {
+		return fallback, nil
+	}
+
+	status, err := command.New(
+		"git", "describe", "--tags", "--always", "--dirty",
+	).RunSilent()
+	if err != nil {
+		return "", fmt.Errorf("run git describe: %w", err)
+	}
+
+	if !status.Success() {
+		logrus.Debugf(
+			"Unable to run git describe, using embedded version: %s",
+			strings.TrimSpace(status.Error()),
+		)
+
+		return fallback, nil
+	}
+
+	return status.OutputTrimNL(), nil
+}
+
 // String returns the string representation of the version info.
 func (i *Info) String() string {
 	b := strings.Builder{}
@@ -211,6 +252,13 @@ func (i *Info) String() string {
 	_, _ = fmt.Fprintf(w, "Compiler:\t%s\n", i.Compiler)
 	_, _ = fmt.Fprintf(w, "Platform:\t%s\n", i.Platform)
 
+	if len(i.Dependencies) > 0 {
+		_, _ = fmt.Fprint(w, "Dependencies:\t\n")
+		for _, name := range slices.Sorted(maps.Keys(i.Dependencies)) {
+			_, _ = fmt.Fprintf(w, "  %s:\t%s\n", name, i.Dependencies[name])
+		}
+	}
+
 	_ = w.Flush()
 
 	return b.String()
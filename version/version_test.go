@@ -34,3 +34,32 @@ func TestVersionJSON(t *testing.T) {
 	require.NoError(t, err)
 	require.NotEmpty(t, json)
 }
+
+func TestVersionDependencies(t *testing.T) {
+	sut := GetVersionInfo()
+	require.NotContains(t, sut.String(), "Dependencies:")
+
+	sut.Dependencies = map[string]string{"zeitgeist": "v0.5.4", "golangci-lint": "v1.63.4"}
+
+	text := sut.String()
+	require.Contains(t, text, "Dependencies:")
+	require.Regexp(t, `(?s)golangci-lint:\s+v1\.63\.4\n.*zeitgeist:\s+v0\.5\.4\n`, text)
+
+	json, err := sut.JSONString()
+	require.NoError(t, err)
+	require.Contains(t, json, `"golangci-lint": "v1.63.4"`)
+}
+
+func TestDescribeVersion(t *testing.T) {
+	res, err := DescribeVersion()
+	require.NoError(t, err)
+	require.NotEmpty(t, res)
+}
+
+func TestDescribeVersionFallback(t *testing.T) {
+	t.Setenv("PATH", "")
+
+	res, err := DescribeVersion()
+	require.NoError(t, err)
+	require.Equal(t, GetVersionInfo().GitVersion, res)
+}